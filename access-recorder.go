@@ -0,0 +1,165 @@
+package config
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// AccessRecorder records the paths resolved with P(), DotP() and SlashP(), and the accessor
+// calls, with the requested types, and whether the default callback was used. Attach it with
+// Record(), e.g. for the duration of the init:
+//
+//	rec := &config.AccessRecorder{}
+//	conf = conf.Record(rec)
+//	... // read the config
+//	rec.Used(), rec.Defaulted(), rec.NeverRead(conf)
+//
+// It's safe for concurrent use.
+type AccessRecorder struct {
+	Logger *zerolog.Logger // if set, every access is logged at the debug level
+
+	mu      sync.Mutex
+	records map[string]*AccessRecord
+}
+
+// AccessRecord is what's recorded for a single absolute path.
+type AccessRecord struct {
+	Path      []string
+	Types     []string // the requested types, e.g. "int" or "[]string", in order of first request
+	Resolved  bool     // the path existed at least once
+	Read      bool     // the value was successfully read with an accessor at least once
+	Defaulted bool     // the default callback was used at least once
+	Count     int      // number of resolutions and accessor calls
+}
+
+// Attaches the recorder to the expression and all the configs derived from it.
+func (c *Config) Record(r *AccessRecorder) (c2 *Config) {
+	c2 = c.ChildCopy()
+	c2.recorder = r
+	return c2
+}
+
+func (c *Config) recordPath(found bool) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.record(c.GetCurrentLocationPlusPath(), "", found, false, false)
+}
+
+// Called by the accessors, deferred, with the ExpressionStatus as it was before the call.
+func (c *Config) recordAccess(typ string, statusBefore ExpressionFailure) {
+	// the default callback is the only thing which raises the status to 2
+	defaulted := statusBefore < ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce &&
+		c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce
+	if defaulted && c.errCollector != nil {
+		// the default was used, so it's not a failure
+		c.errCollector.remove(c.collectedErr)
+	}
+	if c.recorder == nil {
+		return
+	}
+	ok := c.ExpressionStatus == ExpressionStatus_0_Norm
+	// the ListConfig() and MapConfig() are rather a navigation, their items are read later
+	read := ok && !strings.HasSuffix(typ, "*Config")
+	c.recorder.record(c.GetCurrentLocationPlusPath(), typ, ok, read, defaulted)
+}
+
+func (r *AccessRecorder) record(path []string, typ string, resolved, read, defaulted bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.records == nil {
+		r.records = map[string]*AccessRecord{}
+	}
+	rec, ok := r.records[pathKey(path)]
+	if !ok {
+		rec = &AccessRecord{Path: path}
+		r.records[pathKey(path)] = rec
+	}
+	if typ != "" {
+		known := false
+		for _, t := range rec.Types {
+			known = known || t == typ
+		}
+		if !known {
+			rec.Types = append(rec.Types, typ)
+		}
+	}
+	rec.Resolved = rec.Resolved || resolved
+	rec.Read = rec.Read || read
+	rec.Defaulted = rec.Defaulted || defaulted
+	rec.Count++
+	if r.Logger != nil {
+		r.Logger.Debug().Msgf("Wm3kPq8: config access %q as %q: resolved=%v, default=%v",
+			strings.Join(path, "."), typ, resolved, defaulted)
+	}
+}
+
+// Returns all the records, sorted by path.
+func (r *AccessRecorder) Records() []AccessRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := make([]AccessRecord, 0, len(r.records))
+	for _, rec := range r.records {
+		rec := *rec
+		rec.Types = append([]string{}, rec.Types...)
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return pathKey(records[i].Path) < pathKey(records[j].Path)
+	})
+	return records
+}
+
+func (r *AccessRecorder) filter(f func(rec *AccessRecord) bool) (paths [][]string) {
+	for _, rec := range r.Records() {
+		if f(&rec) {
+			paths = append(paths, rec.Path)
+		}
+	}
+	return
+}
+
+// Returns the paths, which were successfully read with accessors, sorted.
+func (r *AccessRecorder) Used() [][]string {
+	return r.filter(func(rec *AccessRecord) bool { return rec.Read })
+}
+
+// Returns the paths, for which the default callbacks were used, sorted.
+func (r *AccessRecorder) Defaulted() [][]string {
+	return r.filter(func(rec *AccessRecord) bool { return rec.Defaulted })
+}
+
+// Returns the leaf paths of the config, which weren't read, neither themselves nor as a part
+// of a bigger value, e.g. with Map(). The paths are absolute, as are the recorded ones.
+func (r *AccessRecorder) NeverRead(c *Config) [][]string {
+	used := r.Used()
+	var paths [][]string
+	for _, path := range getAllPaths(c.DataSubTree, c.GetCurrentLocationPlusPath()...) {
+		read := false
+		for _, u := range used {
+			read = read || isPathPrefix(u, path)
+		}
+		if !read {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return pathKey(paths[i]) < pathKey(paths[j])
+	})
+	return paths
+}
+
+func isPathPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
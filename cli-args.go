@@ -2,8 +2,13 @@ package config
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"strconv"
 	"strings"
+
+	yaml "gopkg.in/yaml.v3"
 )
 
 // Parse command line arguments, based on existing config keys.
@@ -59,3 +64,206 @@ func (c *Config) Args(args ...string) *Config {
 
 	return c
 }
+
+// Loads the config according to the command line, in a Helm-like way:
+//
+//	--config file, -f file          load the file, with parenting; repeat to stack several files,
+//	                                each next extending the previous ones; if there's none, the
+//	                                FileName or Data of the InitContext are loaded, if set
+//	--set a.b[2].c=value            set the value, its type is inferred as in YAML
+//	--set-string a.b=value          set the value as a string
+//	--set-json a.b={"x":1}          set the JSON value
+//
+// Unlike the Flag() and Args(), these can create new paths. The args[0] is the program name.
+// All other args, including unknown options, are left in the InitContext.RemainingArgs,
+// in their order, for the application to handle.
+func (ic *InitContext) FromArgs(args []string) *InitContext {
+	ic.Args = args
+	return ic
+}
+
+type argsSetOp struct {
+	option string
+	path   []string
+	value  string
+}
+
+func (ic *InitContext) loadArgs() (*Config, error) {
+	var files []string
+	var ops []argsSetOp
+	ic.RemainingArgs = []string{}
+
+	args := ic.Args
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			ic.RemainingArgs = append(ic.RemainingArgs, args[i+1:]...)
+			break
+		}
+		name, val, hasVal := strings.Cut(arg, "=")
+		switch name {
+		case "--config", "-f", "--set", "--set-string", "--set-json":
+		default:
+			ic.RemainingArgs = append(ic.RemainingArgs, arg)
+			continue
+		}
+		if !hasVal {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("option %v needs a value", name)
+			}
+			i++
+			val = args[i]
+		}
+		if name == "--config" || name == "-f" {
+			files = append(files, val)
+			continue
+		}
+		path, v, ok := strings.Cut(val, "=")
+		if !ok {
+			return nil, fmt.Errorf("option %v expects path=value, got %q", name, val)
+		}
+		ops = append(ops, argsSetOp{option: name, path: splitArgsPath(path), value: v})
+	}
+
+	var c *Config
+	var err error
+	switch {
+	case len(files) > 0:
+		c = &Config{}
+		for _, fileName := range files {
+			c2 := (&InitContext{FS: ic.FS, Logger: ic.Logger, ExtendOptions: ic.ExtendOptions, Migrations: ic.Migrations}).
+				FromFile(fileName).Err(&err).LoadWithParenting()
+			if err != nil {
+				return nil, err
+			}
+			if _, err = c.ExtendBy_v2_Strict(c2, ic.ExtendOptions...); err != nil {
+				return nil, fmt.Errorf("config file %q: %w", fileName, err)
+			}
+		}
+	case len(ic.FileName) > 0 || len(ic.Data) > 0:
+		ic2 := *ic
+		ic2.Args = nil
+		ic2.Interpolation = false
+		if c = ic2.Err(&err).Load(); err != nil {
+			return nil, err
+		}
+	default:
+		c = &Config{}
+	}
+	if c.DataSubTree == nil {
+		c.DataSubTree = map[string]interface{}{}
+	}
+
+	for _, op := range ops {
+		var v interface{}
+		switch op.option {
+		case "--set":
+			if op.value == "" {
+				v = ""
+			} else if err = yaml.Unmarshal([]byte(op.value), &v); err != nil {
+				v = op.value
+			}
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				// only scalars are inferred, the rest is a string
+				v = op.value
+			}
+		case "--set-string":
+			v = op.value
+		case "--set-json":
+			if err = json.Unmarshal([]byte(op.value), &v); err != nil {
+				return nil, fmt.Errorf("option %v %v: %w", op.option, strings.Join(op.path, "."), err)
+			}
+		}
+		if v, err = normalizeValue(v); err != nil {
+			return nil, err
+		}
+		if err = checkListIndices(c.DataSubTree, op.path); err != nil {
+			return nil, fmt.Errorf("option %v %v: %w", op.option, strings.Join(op.path, "."), err)
+		}
+		if err = set(c.DataSubTree, op.path, v); err != nil {
+			return nil, fmt.Errorf("option %v %v: %w", op.option, strings.Join(op.path, "."), err)
+		}
+	}
+	return c, nil
+}
+
+// Checks, that the path parts, which go into the existing lists, or which will create the new
+// ones, are valid list indices.
+func checkListIndices(tree interface{}, path []string) error {
+	for i, part := range path {
+		if l, ok := tree.([]interface{}); ok {
+			n, err := strconv.Atoi(part)
+			if err != nil || !isListIndex(part) {
+				return &InvalidPathError{Path: path[:i+1], Reason: "invalid list index"}
+			}
+			if n < len(l) && l[n] != nil {
+				tree = l[n]
+				continue
+			}
+			return checkNewListIndices(path, i+1)
+		}
+		next, err := goByExactPath(tree, []string{part})
+		if err != nil || next == nil {
+			return checkNewListIndices(path, i+1)
+		}
+		tree = next
+	}
+	return nil
+}
+
+// Checks the parts from the i on, which are about to be created; the numeric ones create lists.
+func checkNewListIndices(path []string, i int) error {
+	for ; i < len(path); i++ {
+		if _, err := strconv.ParseInt(path[i], 10, 0); err == nil && !isListIndex(path[i]) {
+			return &InvalidPathError{Path: path[:i+1], Reason: "invalid list index"}
+		}
+	}
+	return nil
+}
+
+// Splits the --set path into parts, with the list indices in brackets, e.g. "a.b[2].c" is
+// "a", "b", "2", "c"; a bracket after a dot holds a key with dots, e.g. "a.[b.c].d".
+func splitArgsPath(path string) []string {
+	parts := []string{}
+
+	bracketOpened := false
+	bracketJustClosed := false
+	var buffer bytes.Buffer
+	for _, char := range path {
+		switch {
+		case char == '[' && !bracketOpened:
+			if buffer.Len() > 0 {
+				parts = append(parts, buffer.String())
+				buffer.Reset()
+			}
+			bracketOpened = true
+			continue
+		case char == ']' && bracketOpened:
+			parts = append(parts, buffer.String())
+			buffer.Reset()
+			bracketOpened = false
+			bracketJustClosed = true
+			continue
+		case char == '.' && !bracketOpened:
+			if !bracketJustClosed {
+				parts = append(parts, buffer.String())
+			}
+			buffer.Reset()
+			bracketJustClosed = false
+			continue
+		}
+
+		bracketJustClosed = false
+		buffer.WriteRune(char)
+	}
+
+	if buffer.Len() > 0 {
+		parts = append(parts, buffer.String())
+	}
+
+	return parts
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"io/fs"
 	"strings"
 )
 
@@ -15,6 +16,13 @@ type Config struct {
 	Source                 *Source `json:"-"`
 	relativePathFromParent []string
 	parent                 *Config
+	origin                 string
+	origins                map[string]*valueOrigin // of the values, merged from other configs
+	templates              map[string]*template
+	fsys                   fs.FS // for the ${file:...} of the Interpolate(); if nil, the OS file system is used
+	recorder               *AccessRecorder
+	errCollector           *ErrCollector
+	collectedErr           *collectedErr // the error of the expression, added to the errCollector
 }
 
 type ExpressionFailure int
@@ -37,6 +45,13 @@ func (c *Config) ChildCopy() (c2 *Config) {
 			Source:                 c.Source,
 			relativePathFromParent: nil,
 			parent:                 c,
+			origin:                 c.origin,
+			origins:                c.origins,
+			templates:              c.templates,
+			fsys:                   c.fsys,
+			recorder:               c.recorder,
+			errCollector:           c.errCollector,
+			collectedErr:           c.collectedErr,
 		}
 	} else {
 		c2 = &Config{}
@@ -44,6 +59,11 @@ func (c *Config) ChildCopy() (c2 *Config) {
 	return
 }
 
+// Where the config was loaded from, usually a file name; empty if unknown.
+func (c *Config) Origin() string {
+	return c.origin
+}
+
 func (c *Config) GetCurrentLocationPlusPath(pathParts ...string) (path []string) {
 	path = make([]string, 0, 10)
 	var f func(*Config)
@@ -66,9 +86,10 @@ func (c *Config) P(pathParts ...string) *Config {
 	var err error
 	c2.DataSubTree, err = goByPath(c2.DataSubTree, pathParts)
 	if err != nil {
-		c2.handleError(err)
+		c2.handleError(c.located(err))
 	}
 	c2.relativePathFromParent = pathParts
+	c2.recordPath(err == nil)
 	return c2
 }
 
@@ -90,15 +111,21 @@ func (c *Config) ErrOk() *Config {
 		*c.ErrPtr = nil
 	}
 	c.ExpressionStatus = ExpressionStatus_0_Norm
+	c.collectedErr = nil
 	return c
 }
 
 // Sets ExpressionStatus=failed if it was OK; sets Err, if it wasn't already; sets Ok=false, if it's present;
-// Then panics, unless there is present either of Ok, Err, or dontPanicFlag (it can be set with U()).
+// adds the error to the ErrCollector, if it's present, and it's the first error of the expression;
+// Then panics, unless there is present either of Ok, Err, ErrCollector, or dontPanicFlag (it can be set with U()).
 func (c *Config) handleError(err error) {
 	if err == nil {
 		return
 	} else {
+		if c.errCollector != nil && c.ExpressionStatus == ExpressionStatus_0_Norm {
+			// only the first error of the expression, same as with the Err
+			c.collectedErr = c.errCollector.add(err)
+		}
 		if c.ExpressionStatus < ExpressionStatus_1_Failed {
 			c.ExpressionStatus = ExpressionStatus_1_Failed
 		}
@@ -113,12 +140,17 @@ func (c *Config) handleError(err error) {
 		if c.OkPtr != nil {
 			*c.OkPtr = false
 		}
-		if c.ErrPtr == nil && c.OkPtr == nil && !c.dontPanicFlag {
+		if c.ErrPtr == nil && c.OkPtr == nil && c.errCollector == nil && !c.dontPanicFlag {
 			panic(err)
 		}
 	}
 }
 
+// Makes the path of the path error absolute, relative to the current location.
+func (c *Config) located(err error) error {
+	return withLocation(err, c.GetCurrentLocationPlusPath())
+}
+
 func (c *Config) isExpressionOk() (ok bool) {
 	if c.ErrPtr != nil {
 		return *c.ErrPtr == nil
@@ -173,7 +205,7 @@ func (c *Config) Set(pathParts []string, v interface{}) {
 func (c *Config) NonThreadSafe_Set(pathParts []string, v interface{}) {
 	err := set(c.DataSubTree, pathParts, v)
 	if err != nil {
-		c.handleError(err)
+		c.handleError(c.located(err))
 	}
 }
 
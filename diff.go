@@ -0,0 +1,211 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rusriver/config/v2/deepcopy"
+)
+
+type ChangeKind string
+
+const (
+	ChangeKind_Added       ChangeKind = "added"
+	ChangeKind_Removed     ChangeKind = "removed"
+	ChangeKind_Modified    ChangeKind = "modified"
+	ChangeKind_TypeChanged ChangeKind = "type-changed" // e.g. a string became a map
+)
+
+// Change is a single difference, found by Diff(). The Path is relative to the compared locations.
+// The Old is nil for the added values, and the New is nil for the removed ones.
+type Change struct {
+	Path []string
+	Kind ChangeKind
+	Old  interface{}
+	New  interface{}
+}
+
+func (ch Change) String() string {
+	switch ch.Kind {
+	case ChangeKind_Added:
+		return fmt.Sprintf("%q: added %v", strings.Join(ch.Path, "."), ch.New)
+	case ChangeKind_Removed:
+		return fmt.Sprintf("%q: removed %v", strings.Join(ch.Path, "."), ch.Old)
+	}
+	return fmt.Sprintf("%q: %v %v -> %v", strings.Join(ch.Path, "."), ch.Kind, ch.Old, ch.New)
+}
+
+// Diff() compares the config with the other one, at their current locations, and returns the
+// changes, which turn the former into the latter, sorted by path. The maps are compared by key,
+// and the lists item by item, so an item inserted in the middle shows as the modified items
+// and an added one at the end. The numbers are equal, if their values are, be they int or float.
+func (c *Config) Diff(other *Config) []Change {
+	var changes []Change
+	diffValues(c.DataSubTree, other.DataSubTree, nil, &changes)
+	return changes
+}
+
+func diffValues(a, b interface{}, path []string, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(av)+len(bv))
+			for k := range av {
+				keys = append(keys, k)
+			}
+			for k := range bv {
+				if _, ok := av[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				x, inA := av[k]
+				y, inB := bv[k]
+				switch {
+				case !inB:
+					*changes = append(*changes, Change{Path: appendPath(path, k), Kind: ChangeKind_Removed, Old: x})
+				case !inA:
+					*changes = append(*changes, Change{Path: appendPath(path, k), Kind: ChangeKind_Added, New: y})
+				default:
+					diffValues(x, y, appendPath(path, k), changes)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			for i := 0; i < len(av) || i < len(bv); i++ {
+				switch {
+				case i >= len(bv):
+					*changes = append(*changes, Change{Path: appendPath(path, strconv.Itoa(i)), Kind: ChangeKind_Removed, Old: av[i]})
+				case i >= len(av):
+					*changes = append(*changes, Change{Path: appendPath(path, strconv.Itoa(i)), Kind: ChangeKind_Added, New: bv[i]})
+				default:
+					diffValues(av[i], bv[i], appendPath(path, strconv.Itoa(i)), changes)
+				}
+			}
+			return
+		}
+	}
+	if schemaEqual(a, b) {
+		return
+	}
+	kind := ChangeKind_Modified
+	if (&Config{DataSubTree: a}).Kind() != (&Config{DataSubTree: b}).Kind() {
+		kind = ChangeKind_TypeChanged
+	}
+	*changes = append(*changes, Change{Path: path, Kind: kind, Old: a, New: b})
+}
+
+// MergePatch() returns the RFC 7386 JSON Merge Patch, which turns the config into the other one,
+// both at their current locations. As with any merge patch, the nulls of the other config can't
+// be expressed, they would remove the keys instead, and the lists are replaced as a whole.
+func (c *Config) MergePatch(other *Config) []byte {
+	b, err := json.Marshal(mergePatchOf(c.DataSubTree, other.DataSubTree))
+	if err != nil {
+		c.handleError(err)
+		return nil
+	}
+	return b
+}
+
+func mergePatchOf(a, b interface{}) interface{} {
+	av, ok := a.(map[string]interface{})
+	if !ok {
+		return b
+	}
+	bv, ok := b.(map[string]interface{})
+	if !ok {
+		return b
+	}
+	patch := map[string]interface{}{}
+	for k := range av {
+		if _, ok := bv[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, y := range bv {
+		x, ok := av[k]
+		if !ok {
+			patch[k] = y
+			continue
+		}
+		if _, isMap := y.(map[string]interface{}); isMap {
+			if _, isMap := x.(map[string]interface{}); isMap {
+				if p := mergePatchOf(x, y).(map[string]interface{}); len(p) > 0 {
+					patch[k] = p
+				}
+				continue
+			}
+		}
+		if !schemaEqual(x, y) {
+			patch[k] = y
+		}
+	}
+	return patch
+}
+
+// Applies the RFC 7386 JSON Merge Patch to the config at the current location: the maps are
+// merged recursively, the nulls remove the keys, and everything else replaces the values. With
+// the Source, it's a single command of the updater, same as ApplyPatch().
+func (c *Config) ApplyMergePatch(patchJSON []byte) {
+	patch, err := parseMergePatch(patchJSON)
+	if err != nil {
+		c.handleError(err)
+		return
+	}
+	c.applyPatch(patch)
+}
+
+// Applies the RFC 7386 JSON Merge Patch, same as ApplyMergePatch(), but ignoring the Source.
+func (c *Config) NonThreadSafe_ApplyMergePatch(patchJSON []byte) {
+	patch, err := parseMergePatch(patchJSON)
+	if err != nil {
+		c.handleError(err)
+		return
+	}
+	c.nonThreadSafe_ApplyPatch(patch)
+}
+
+type mergePatch struct {
+	v interface{}
+}
+
+func parseMergePatch(b []byte) (*mergePatch, error) {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("Invalid JSON merge patch: %w", err)
+	}
+	v, err := normalizeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return &mergePatch{v: v}, nil
+}
+
+func (patch *mergePatch) apply(doc interface{}) (interface{}, error) {
+	return applyMergePatch(deepcopy.Copy(doc), patch.v), nil
+}
+
+func applyMergePatch(target, patch interface{}) interface{} {
+	pv, ok := patch.(map[string]interface{})
+	if !ok {
+		return deepcopy.Copy(patch)
+	}
+	tv, ok := target.(map[string]interface{})
+	if !ok {
+		tv = map[string]interface{}{}
+	}
+	for k, v := range pv {
+		if v == nil {
+			delete(tv, k)
+		} else {
+			tv[k] = applyMergePatch(tv[k], v)
+		}
+	}
+	return tv
+}
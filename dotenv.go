@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseDotEnvFile reads a .env file from the given filename.
+func parseDotEnvFile(inc *includer, filename string) (*Config, error) {
+	c, err := inc.readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parseDotEnv(c)
+}
+
+// parseDotEnv parses the dotenv syntax into a flat map of variables:
+//
+//	# comment
+//	export A=1
+//	B = unquoted value  # comment
+//	C='single-quoted, taken literally,
+//	may span lines'
+//	D="double-quoted, with \n, \t, \" and \\ escapes,
+//	may span lines too"
+func parseDotEnv(c []byte) (*Config, error) {
+	vars := map[string]interface{}{}
+	s := strings.ReplaceAll(string(c), "\r\n", "\n")
+	line := 1
+	for len(s) > 0 {
+		// one iteration per variable, or per empty or comment line
+		var l string
+		l, s = cutLine(s)
+		lineStart := line
+		line++
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		if rest := strings.TrimPrefix(l, "export"); len(rest) < len(l) && strings.IndexAny(rest, " \t") == 0 {
+			l = strings.TrimSpace(rest)
+		}
+		name, value, ok := strings.Cut(l, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t\"'") {
+			return nil, fmt.Errorf("line %v: expected NAME=value", lineStart)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+			quote := value[0]
+			// the quoted value may span several lines
+			value = value[1:]
+			var b strings.Builder
+			closed := false
+			for !closed {
+				for i := 0; i < len(value); i++ {
+					ch := value[i]
+					if ch == quote {
+						closed = true
+						rest := strings.TrimSpace(value[i+1:])
+						if rest != "" && !strings.HasPrefix(rest, "#") {
+							return nil, fmt.Errorf("line %v: unexpected text after the closing quote", line-1)
+						}
+						break
+					}
+					if ch == '\\' && quote == '"' && i+1 < len(value) {
+						i++
+						switch value[i] {
+						case 'n':
+							b.WriteByte('\n')
+						case 't':
+							b.WriteByte('\t')
+						case 'r':
+							b.WriteByte('\r')
+						default:
+							b.WriteByte(value[i])
+						}
+						continue
+					}
+					b.WriteByte(ch)
+				}
+				if !closed {
+					if len(s) == 0 {
+						return nil, fmt.Errorf("line %v: unterminated quoted value", lineStart)
+					}
+					b.WriteByte('\n')
+					value, s = cutLine(s)
+					line++
+				}
+			}
+			vars[name] = b.String()
+			continue
+		}
+
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		vars[name] = strings.TrimSpace(value)
+	}
+	return &Config{DataSubTree: vars}, nil
+}
+
+func cutLine(s string) (line, rest string) {
+	line, rest, _ = strings.Cut(s, "\n")
+	return
+}
+
+// Same as ExtendByEnvs_WithPrefix(), but the variables are taken from the dotEnv config,
+// loaded with the InitContext.FromDotEnv(), instead of the process environment. To layer
+// them, call in order of increasing priority, e.g. conf.ExtendByDotEnv_WithPrefix(dotEnv, "app").
+// ExtendByEnvs_WithPrefix("app"), and the process environment overrides the .env file.
+func (c *Config) ExtendByDotEnv_WithPrefix(dotEnv *Config, prefix string) *Config {
+	return c.extendByDotEnv(dotEnv, DefaultEnvMapper(prefix), false)
+}
+
+// Same as ExtendByEnvs_WithMapper(), but the variables are taken from the dotEnv config.
+func (c *Config) ExtendByDotEnv_WithMapper(dotEnv *Config, m *EnvMapper) *Config {
+	return c.extendByDotEnv(dotEnv, m, true)
+}
+
+func (c *Config) extendByDotEnv(dotEnv *Config, m *EnvMapper, warnCollisions bool) *Config {
+	vars := dotEnv.MapString()
+	return c.extendByEnvs(m, func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}, warnCollisions)
+}
@@ -3,6 +3,7 @@ package config
 import "time"
 
 func (c *Config) Duration(defaultValueFunc ...func() time.Duration) time.Duration {
+	defer c.recordAccess("time.Duration", c.ExpressionStatus)
 	n := c.DataSubTree
 	if str, ok := n.(string); ok {
 		dur, err := time.ParseDuration(str)
@@ -10,7 +11,7 @@ func (c *Config) Duration(defaultValueFunc ...func() time.Duration) time.Duratio
 			return dur
 		}
 	}
-	c.handleError(typeMismatchError("string", n))
+	c.handleError(c.located(typeMismatchError("string", n)))
 	if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 		if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 			panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -23,8 +24,9 @@ func (c *Config) Duration(defaultValueFunc ...func() time.Duration) time.Duratio
 }
 
 func (c *Config) ListDuration(defaultValueFunc ...func() []time.Duration) []time.Duration {
+	defer c.recordAccess("[]time.Duration", c.ExpressionStatus)
 	undef := make([]time.Duration, 0)
-	l := c.List()
+	l := c.list()
 
 	l2 := make([]time.Duration, 0, len(l))
 	for _, n := range l {
@@ -36,7 +38,7 @@ func (c *Config) ListDuration(defaultValueFunc ...func() []time.Duration) []time
 				goto OK
 			}
 		}
-		c.handleError(typeMismatchError("string", n))
+		c.handleError(c.located(typeMismatchError("string", n)))
 		if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 			if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 				panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -53,8 +55,9 @@ func (c *Config) ListDuration(defaultValueFunc ...func() []time.Duration) []time
 }
 
 func (c *Config) MapDuration(defaultValueFunc ...func() map[string]time.Duration) map[string]time.Duration {
+	defer c.recordAccess("map[string]time.Duration", c.ExpressionStatus)
 	undef := make(map[string]time.Duration)
-	m := c.Map()
+	m := c.mapAny()
 
 	m2 := make(map[string]time.Duration, len(m))
 	for k, n := range m {
@@ -66,7 +69,7 @@ func (c *Config) MapDuration(defaultValueFunc ...func() map[string]time.Duration
 				goto OK
 			}
 		}
-		c.handleError(typeMismatchError("string", n))
+		c.handleError(c.located(typeMismatchError("string", n)))
 		if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 			if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 				panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
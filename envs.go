@@ -1,28 +1,263 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
+type EnvCase int
+
+const (
+	EnvCase_Upper EnvCase = iota
+	EnvCase_Lower
+	EnvCase_AsIs
+)
+
+// EnvMapper defines how the config paths are mapped to the env variable names.
+// The zero value maps "a.s-d.0" to "A_SD_0".
+type EnvMapper struct {
+	Prefix          string // used as is, e.g. "APP_"
+	Separator       string // between the path parts; "_" if empty
+	DashReplacement string // what the dashes in the keys become; empty means they're removed
+	Case            EnvCase
+	NoListItems     bool            // if set, lists are only overridden as a whole
+	Logger          *zerolog.Logger // for the name collision warnings; the global one, if nil
+}
+
+// The mapping used by the ExtendByEnvs_WithPrefix().
+func DefaultEnvMapper(prefix string) *EnvMapper {
+	if prefix != "" {
+		prefix = strings.ToUpper(prefix) + "_"
+	}
+	return &EnvMapper{Prefix: prefix}
+}
+
+func (m *EnvMapper) separator() string {
+	if m.Separator == "" {
+		return "_"
+	}
+	return m.Separator
+}
+
+// Returns the env variable name for the path.
+func (m *EnvMapper) Name(pathParts []string) string {
+	parts := make([]string, len(pathParts))
+	for i, p := range pathParts {
+		parts[i] = strings.ReplaceAll(p, "-", m.DashReplacement)
+	}
+	name := strings.Join(parts, m.separator())
+	switch m.Case {
+	case EnvCase_Upper:
+		name = strings.ToUpper(name)
+	case EnvCase_Lower:
+		name = strings.ToLower(name)
+	}
+	return m.Prefix + name
+}
+
+// The reverse of Name(), as good as it can be: removed dashes can't be restored, and
+// upper-cased names are lower-cased back. Returns nil, if the name doesn't have the prefix.
+func (m *EnvMapper) Path(name string) []string {
+	if !strings.HasPrefix(name, m.Prefix) {
+		return nil
+	}
+	parts := strings.Split(name[len(m.Prefix):], m.separator())
+	for i, p := range parts {
+		if m.DashReplacement != "" && m.DashReplacement != m.separator() {
+			p = strings.ReplaceAll(p, m.DashReplacement, "-")
+		}
+		if m.Case == EnvCase_Upper {
+			p = strings.ToLower(p)
+		}
+		parts[i] = p
+	}
+	return parts
+}
+
+// Returns the env names, which more than one path of the config map to, with these paths.
+func (m *EnvMapper) Collisions(c *Config) map[string][][]string {
+	byName := map[string][][]string{}
+	for _, pathParts := range m.paths(c.DataSubTree) {
+		name := m.Name(pathParts)
+		byName[name] = append(byName[name], pathParts)
+	}
+	collisions := map[string][][]string{}
+	for name, paths := range byName {
+		if len(paths) > 1 {
+			collisions[name] = paths
+		}
+	}
+	return collisions
+}
+
+// Paths which can be overridden: the lists first, then the leaves.
+func (m *EnvMapper) paths(tree interface{}) [][]string {
+	paths := append(getListPaths(tree), getAllPaths(tree)...)
+	if !m.NoListItems {
+		return paths
+	}
+	paths2 := make([][]string, 0, len(paths))
+	for _, pathParts := range paths {
+		if !hasListOnPath(tree, pathParts) {
+			paths2 = append(paths2, pathParts)
+		}
+	}
+	return paths2
+}
+
+// Tells if there's a list on the path, excluding the path itself.
+func hasListOnPath(tree interface{}, pathParts []string) bool {
+	for i := range pathParts {
+		if v, _ := goByPath(tree, pathParts[:i]); isList(v) {
+			return true
+		}
+	}
+	return false
+}
+
 // Fetch data from system env using prefix, based on existing config keys.
 // VERY IMPORTANT USAGE NOTE: this can override what is already present in the config,
 // but it cannot create new things, which were not in the config.
+// The env value is converted to the type of the value it overrides: int, float64, bool,
+// or a list; lists are set from a JSON array, or from comma-separated values, e.g.
+// for "hosts: [a, b]" there can be HOSTS=c,d,e, as well as HOSTS_0=c. If the conversion
+// fails, the error names the env variable, and the value is left as is.
 func (c *Config) ExtendByEnvs_WithPrefix(prefix string) *Config {
-	if prefix != "" {
-		prefix = strings.ToUpper(prefix) + "_"
+	// the collisions aren't reported here, as the removed dashes make them common
+	return c.extendByEnvs(DefaultEnvMapper(prefix), syscall.Getenv, false)
+}
+
+// Same as ExtendByEnvs_WithPrefix(), but with custom names mapping. If several paths map to
+// the same env variable, a warning is logged to the m.Logger.
+func (c *Config) ExtendByEnvs_WithMapper(m *EnvMapper) *Config {
+	return c.extendByEnvs(m, syscall.Getenv, true)
+}
+
+func (c *Config) extendByEnvs(m *EnvMapper, lookup func(name string) (string, bool), warnCollisions bool) *Config {
+	if warnCollisions {
+		logger := m.Logger
+		if logger == nil {
+			logger = &log.Logger
+		}
+		for name, paths := range m.Collisions(c) {
+			logger.Warn().Msgf("tE4xWq9: env variable name collision: '%v' maps to %v", name, paths)
+		}
 	}
-	paths := getAllPaths(c.DataSubTree)
-	for _, pathParts := range paths {
-		k := strings.ReplaceAll(strings.ToUpper(strings.Join(pathParts, "_")), "-", "")
-		if val, exist := syscall.Getenv(prefix + k); exist {
-			c.Set(pathParts, val)
+	// if a list is overridden as a whole, its items aren't looked at
+	overriddenLists := [][]string{}
+NEXT_PATH:
+	for _, pathParts := range m.paths(c.DataSubTree) {
+		for _, l := range overriddenLists {
+			if len(pathParts) > len(l) && isPathPrefix(l, pathParts) {
+				continue NEXT_PATH
+			}
+		}
+		name := m.Name(pathParts)
+		if val, exist := lookup(name); exist {
+			if current, _ := goByPath(c.DataSubTree, pathParts); isList(current) {
+				overriddenLists = append(overriddenLists, pathParts)
+			}
+			c.setFromEnv(name, pathParts, val)
 		}
 	}
 	return c
 }
 
+func isList(v interface{}) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
+
+func (c *Config) setFromEnv(name string, pathParts []string, val string) {
+	current, _ := goByPath(c.DataSubTree, pathParts)
+	v, err := convertEnvValue(current, val)
+	if err != nil {
+		// the value itself isn't reported, as it may be a secret
+		expected := "scalar or list"
+		var tme *TypeMismatchError
+		if errors.As(err, &tme) {
+			expected = tme.Expected
+		}
+		c.handleError(fmt.Errorf("Env variable %v can't override %q: expected %v",
+			name, strings.Join(pathParts, "."), expected))
+		return
+	}
+	c.Set(pathParts, v)
+}
+
+// Converts the env value to the type of the current value.
+func convertEnvValue(current interface{}, val string) (interface{}, error) {
+	switch current := current.(type) {
+	case int:
+		i, err := strconv.ParseInt(strings.TrimSpace(val), 10, 0)
+		if err != nil {
+			return nil, typeMismatchError("int", val)
+		}
+		return int(i), nil
+	case float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return nil, typeMismatchError("float64", val)
+		}
+		return f, nil
+	case bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(val))
+		if err != nil {
+			return nil, typeMismatchError("bool", val)
+		}
+		return b, nil
+	case []interface{}:
+		// items are converted to the type of the current items, if they are all the same
+		var itemType interface{}
+		for i, item := range current {
+			if i == 0 {
+				itemType = item
+			} else if fmt.Sprintf("%T", item) != fmt.Sprintf("%T", itemType) {
+				itemType = nil
+				break
+			}
+		}
+		var items []interface{}
+		if strings.HasPrefix(strings.TrimSpace(val), "[") {
+			var l interface{}
+			if err := json.Unmarshal([]byte(val), &l); err != nil {
+				return nil, typeMismatchError("JSON array", val)
+			}
+			items = l.([]interface{})
+		} else if val != "" {
+			for _, s := range strings.Split(val, ",") {
+				items = append(items, strings.TrimSpace(s))
+			}
+		}
+		l := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}, nil:
+			default:
+				if itemType != nil {
+					var err error
+					if item, err = convertEnvValue(itemType, fmt.Sprint(item)); err != nil {
+						return nil, err
+					}
+				}
+			}
+			l = append(l, item)
+		}
+		return normalizeValue(l)
+	case map[string]interface{}:
+		return nil, typeMismatchError("scalar or list", current)
+	}
+	return val, nil
+}
+
 // Unlike the ExtendByEnvs_WithPrefix(), this function allows to create new nodes in the config,
 // based on the envs. It scans all envs matching the specified prefix, then strips the prefix,
 // then what is left is used as a valid dot-path as is. For example, if the prefix was PRFX,
@@ -39,3 +274,30 @@ func (c *Config) ExtendByEnvsV2_WithPrefix(prefix string) {
 		}
 	}
 }
+
+// Like the ExtendByEnvsV2_WithPrefix(), creates new nodes in the config, but with names mapped
+// by the m, e.g. with the Separator "__", the APP__SERVER__PORT would set "server.port".
+// The env variables, which map to existing paths, override them as the ExtendByEnvs_WithMapper() does.
+// The m.Prefix must not be empty, else it would import the whole process environment.
+func (c *Config) ExtendByEnvsV2_WithMapper(m *EnvMapper) *Config {
+	if m.Prefix == "" {
+		c.handleError(fmt.Errorf("ExtendByEnvsV2_WithMapper() needs a prefix, else it imports the whole environment"))
+		return c
+	}
+	known := map[string][]string{}
+	for _, pathParts := range m.paths(c.DataSubTree) {
+		known[m.Name(pathParts)] = pathParts
+	}
+	for _, e := range os.Environ() {
+		name, val, _ := strings.Cut(e, "=")
+		if !strings.HasPrefix(name, m.Prefix) {
+			continue
+		}
+		if pathParts, ok := known[name]; ok {
+			c.setFromEnv(name, pathParts, val)
+		} else {
+			c.Set(m.Path(name), val)
+		}
+	}
+	return c
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrCollector gathers the failures of many expressions, instead of only the first one, e.g.
+// to report all the misconfigured keys at startup at once:
+//
+//	ec := &config.ErrCollector{}
+//	c := conf.Collect(ec)
+//	port := c.P("server", "port").Int()
+//	timeout := c.P("server", "timeout").Duration()
+//	...
+//	if err := ec.Err(); err != nil {
+//		log.Fatal().Err(err).Msg("bad config")
+//	}
+//
+// Only the first error of each expression is collected, the rest are its consequences, and
+// the expressions, which use the default callbacks, are not failures. The
+// path errors have the absolute paths, and the TypeMismatchError has the expected type, too.
+// It's safe for concurrent use.
+type ErrCollector struct {
+	mu   sync.Mutex
+	errs []*collectedErr
+}
+
+type collectedErr struct {
+	err error
+}
+
+// Attaches the collector to the expression and all the configs derived from it. With it, the
+// failing expressions don't panic, same as with Err() or Ok().
+func (c *Config) Collect(ec *ErrCollector) (c2 *Config) {
+	c2 = c.ChildCopy()
+	c2.errCollector = ec
+	return c2
+}
+
+func (ec *ErrCollector) add(err error) *collectedErr {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ce := &collectedErr{err: err}
+	ec.errs = append(ec.errs, ce)
+	return ce
+}
+
+func (ec *ErrCollector) remove(ce *collectedErr) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	for i, e := range ec.errs {
+		if e == ce {
+			ec.errs = append(ec.errs[:i], ec.errs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Returns the collected errors, in order.
+func (ec *ErrCollector) Errors() []error {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	errs := make([]error, 0, len(ec.errs))
+	for _, ce := range ec.errs {
+		errs = append(errs, ce.err)
+	}
+	return errs
+}
+
+// Returns nil, if there were no errors, or the *MultiError with all of them.
+func (ec *ErrCollector) Err() error {
+	errs := ec.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: errs}
+}
+
+// Resets the collector, to reuse it.
+func (ec *ErrCollector) Reset() {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.errs = nil
+}
+
+// MultiError is the aggregate of errors: errors.Is() and errors.As() look into all of them.
+// The Is() and As() make it so before Go 1.20 too; since then, the Unwrap() []error does.
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	ss := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		ss = append(ss, err.Error())
+	}
+	return strings.Join(ss, "\n")
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+func (e *MultiError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *MultiError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrParentingCycle = errors.New("config file inheritance cycle")
+
+// ParentingError is reported by the LoadWithParenting(), if any file in the inheritance
+// tree fails to load, or if a file includes itself. The Chain is the sequence of files,
+// starting from the root one, which led to the FileName, including it.
+type ParentingError struct {
+	FileName string
+	Chain    []string
+	Err      error
+}
+
+func (e *ParentingError) Error() string {
+	return fmt.Sprintf("config file %q: %v (inheritance chain: %v)",
+		e.FileName, e.Err, strings.Join(e.Chain, " -> "))
+}
+
+func (e *ParentingError) Unwrap() error {
+	return e.Err
+}
+
+// MergeConflictError is returned by ExtendBy_v2_Strict(), and reported by ExtendBy_v2(),
+// with the ConflictPolicy_Error.
+type MergeConflictError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *MergeConflictError) Error() string {
+	ss := make([]string, 0, len(e.Conflicts))
+	for _, mc := range e.Conflicts {
+		ss = append(ss, mc.String())
+	}
+	return fmt.Sprintf("%v merge conflict(s): %v", len(e.Conflicts), strings.Join(ss, "; "))
+}
+
+// IncludeError is reported, if an included file fails to load, or if a file includes itself.
+// The Chain is the sequence of files, starting from the root one, which led to the FileName, including it.
+type IncludeError struct {
+	FileName string
+	Chain    []string
+	Err      error
+}
+
+func (e *IncludeError) Error() string {
+	return fmt.Sprintf("included config file %q: %v (include chain: %v)",
+		e.FileName, e.Err, strings.Join(e.Chain, " -> "))
+}
+
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownKeysError is reported by UnknownKeys(), if the Fail option is set.
+type UnknownKeysError struct {
+	Keys []UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	ss := make([]string, 0, len(e.Keys))
+	for _, u := range e.Keys {
+		ss = append(ss, u.String())
+	}
+	return fmt.Sprintf("%v unknown key(s): %v", len(e.Keys), strings.Join(ss, "; "))
+}
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrTypeMismatch    = errors.New("type mismatch")
+	ErrIndexOutOfRange = errors.New("index out of range")
+	ErrInvalidPath     = errors.New("invalid path")
+)
+
+// NotFoundError is reported, if a map key doesn't exist. It's an ErrNotFound. The paths of
+// this and the other path errors below are absolute, as the GetCurrentLocationPlusPath() gives.
+type NotFoundError struct {
+	Path []string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Nonexistent map key at %q", strings.Join(e.Path, "."))
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// IndexOutOfRangeError is reported, if a list has no item at the index. It's an ErrIndexOutOfRange.
+type IndexOutOfRangeError struct {
+	Path []string
+	Len  int
+}
+
+func (e *IndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("Index out of range at %q: list has only %v items", strings.Join(e.Path, "."), e.Len)
+}
+
+func (e *IndexOutOfRangeError) Is(target error) bool {
+	return target == ErrIndexOutOfRange
+}
+
+// InvalidPathError is reported, if the path can't be followed: it has an empty part, a list
+// index isn't a number, or there's a scalar value on the way. It's an ErrInvalidPath.
+type InvalidPathError struct {
+	Path   []string
+	Reason string
+}
+
+func (e *InvalidPathError) Error() string {
+	return fmt.Sprintf("Invalid path %q: %v", strings.Join(e.Path, "."), e.Reason)
+}
+
+func (e *InvalidPathError) Is(target error) bool {
+	return target == ErrInvalidPath
+}
+
+// TypeMismatchError is reported by the accessors, if the value is of a wrong type, or can't be
+// converted, then the Err is the conversion error. It's an ErrTypeMismatch.
+type TypeMismatchError struct {
+	Path     []string
+	Expected string
+	Got      string
+	Err      error
+}
+
+func (e *TypeMismatchError) Error() string {
+	s := fmt.Sprintf("Type mismatch at %q: expected %s; got %s", strings.Join(e.Path, "."), e.Expected, e.Got)
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *TypeMismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+func (e *TypeMismatchError) Unwrap() error {
+	return e.Err
+}
+
+// withLocation makes the path of the path error absolute, prefixing it with the location.
+// Other errors are returned as is.
+func withLocation(err error, location []string) error {
+	if len(location) == 0 {
+		return err
+	}
+	abs := func(path []string) []string {
+		return append(append(make([]string, 0, len(location)+len(path)), location...), path...)
+	}
+	switch e := err.(type) {
+	case *NotFoundError:
+		return &NotFoundError{Path: abs(e.Path)}
+	case *IndexOutOfRangeError:
+		return &IndexOutOfRangeError{Path: abs(e.Path), Len: e.Len}
+	case *InvalidPathError:
+		return &InvalidPathError{Path: abs(e.Path), Reason: e.Reason}
+	case *TypeMismatchError:
+		return &TypeMismatchError{Path: abs(e.Path), Expected: e.Expected, Got: e.Got, Err: e.Err}
+	case *PatchError:
+		return &PatchError{Index: e.Index, Op: e.Op, Path: e.Path, Err: withLocation(e.Err, location)}
+	}
+	return err
+}
+
+// JSONPathError is reported by JSONPath(), if the expression is invalid. It's an ErrInvalidPath.
+type JSONPathError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *JSONPathError) Error() string {
+	return fmt.Sprintf("Invalid JSONPath %q at %v: %v", e.Expr, e.Pos, e.Msg)
+}
+
+func (e *JSONPathError) Is(target error) bool {
+	return target == ErrInvalidPath
+}
+
+var ErrPatchTestFailed = errors.New("test failed")
+
+// PatchError is reported by ApplyPatch(), if an operation of the JSON Patch fails, or is invalid.
+// The Index is of the operation in the patch, and the Path is its JSON Pointer, as given, so
+// relative to the patched location. The Err is ErrPatchTestFailed, if the "test" failed, or
+// else usually one of the path errors above.
+type PatchError struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("JSON patch operation %v (%v %q): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
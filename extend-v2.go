@@ -1,73 +1,419 @@
 package config
 
 import (
-	"reflect"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rusriver/config/v2/deepcopy"
+)
+
+type MergeStrategy int
+
+const (
+	// Maps are merged recursively, lists are merged index by index. This is the default.
+	MergeStrategy_Merge MergeStrategy = iota
+	// The child value replaces the parent one as a whole.
+	MergeStrategy_Replace
+	// The child list items are added after the parent ones.
+	MergeStrategy_Append
+	// The child list items are added before the parent ones.
+	MergeStrategy_Prepend
+	// The list items which are maps are matched by the value of the id field; matched items
+	// are merged recursively, unmatched ones are appended.
+	MergeStrategy_MergeById
+)
+
+// Directives, recognized in the child config. A strategy directive is a map with a single
+// key, holding the value to merge, e.g. {"$append": [1, 2]}; the "$merge-by-id" may also have
+// an "$id" key, naming the id field. The "$delete" string, used as a map value, drops the
+// inherited key; {"$delete": true} does the same.
+const (
+	Directive_Replace   = "$replace"
+	Directive_Append    = "$append"
+	Directive_Prepend   = "$prepend"
+	Directive_Merge     = "$merge"
+	Directive_MergeById = "$merge-by-id"
+	Directive_Id        = "$id"
+	Directive_Delete    = "$delete"
+)
+
+var directiveStrategies = map[string]MergeStrategy{
+	Directive_Replace:   MergeStrategy_Replace,
+	Directive_Append:    MergeStrategy_Append,
+	Directive_Prepend:   MergeStrategy_Prepend,
+	Directive_Merge:     MergeStrategy_Merge,
+	Directive_MergeById: MergeStrategy_MergeById,
+}
+
+// MergeRule sets the strategy for a path, relative to the root of the merge.
+// A "*" path part matches any single map key or list index.
+type MergeRule struct {
+	Path     []string
+	Strategy MergeStrategy
+	IdField  string // for MergeStrategy_MergeById; if empty, the ExtendBy_Options.IdField is used
+}
+
+// ConflictPolicy decides what happens when the parent and the child have values of different
+// kinds at the same path, e.g. a map in the parent and a scalar in the child.
+type ConflictPolicy int
+
+const (
+	// Child scalars win, but child maps and lists don't replace a parent value of other kind.
+	// This is how ExtendBy_v2() always worked.
+	ConflictPolicy_Default ConflictPolicy = iota
+	ConflictPolicy_ChildWins
+	ConflictPolicy_ParentWins
+	// Same as ParentWins, but the ExtendBy_v2() fails, and the receiver stays unchanged.
+	ConflictPolicy_Error
 )
 
+type ExtendBy_Options struct {
+	ListStrategy   MergeStrategy // used for lists, not matched by any of Rules
+	IdField        string        // default id field for MergeStrategy_MergeById
+	Rules          []MergeRule
+	ConflictPolicy ConflictPolicy
+}
+
+// MergeConflict describes values of different kinds at the same path; 1 is the parent, 2 is the child.
+// The origins are of the values, e.g. with the multi-level parenting, the Origin1 is the file of
+// the parent, which set the conflicting value.
+type MergeConflict struct {
+	Path    []string
+	Type1   Kind
+	Type2   Kind
+	Origin1 string
+	Origin2 string
+}
+
+func (mc MergeConflict) String() string {
+	return fmt.Sprintf("%q: %v (from %q) vs %v (from %q)",
+		strings.Join(mc.Path, "."), mc.Type1, mc.Origin1, mc.Type2, mc.Origin2)
+}
+
 // ExtendBy() extends current config with another config: i.e. all values
 // from another config are added to the current config, and overwritten
 // with new values if already present. It implements prototype-based inheritance.
-func (c *Config) ExtendBy_v2(c2 *Config) *Config {
-	c.DataSubTree = extend_v2(c.DataSubTree, c2.DataSubTree)
+// The merge strategies can be set with options, and the directives in c2 take
+// precedence over them.
+func (c *Config) ExtendBy_v2(c2 *Config, f ...func(opts *ExtendBy_Options)) *Config {
+	_, err := c.ExtendBy_v2_Strict(c2, f...)
+	if err != nil {
+		c.handleError(err)
+	}
 	return c
 }
 
-// Recursively extends c1 with c2
-func extend_v2(c1 interface{}, c2 interface{}) interface{} {
+// Same as ExtendBy_v2(), but returns all the conflicts met, resolved according to the
+// ConflictPolicy. With the ConflictPolicy_Error, the *MergeConflictError is returned
+// if there were any, and the receiver is left unchanged.
+func (c *Config) ExtendBy_v2_Strict(c2 *Config, f ...func(opts *ExtendBy_Options)) (conflicts []MergeConflict, err error) {
+	opts := &ExtendBy_Options{
+		ListStrategy: MergeStrategy_Merge,
+		IdField:      "id",
+	}
+	for _, f := range f {
+		f(opts)
+	}
+	m := &merger{opts: opts, c1: c, c2: c2, origins: map[string]*valueOrigin{}}
+	c1 := c.DataSubTree
+	if opts.ConflictPolicy == ConflictPolicy_Error {
+		c1 = deepcopy.Copy(c1)
+	}
+	c1 = m.merge(nil, c1, c2.DataSubTree)
+	if opts.ConflictPolicy == ConflictPolicy_Error && len(m.conflicts) > 0 {
+		return m.conflicts, &MergeConflictError{Conflicts: m.conflicts}
+	}
+	c.DataSubTree = c1
+	c.updateOrigins(m.origins)
+	return m.conflicts, nil
+}
 
-	if reflect.TypeOf(c1) == reflect.TypeOf(c2) {
+type merger struct {
+	opts      *ExtendBy_Options
+	c1        *Config
+	c2        *Config
+	origins   map[string]*valueOrigin // of the values of the c2, put into the c1, by the path key
+	conflicts []MergeConflict
+}
 
-		switch c1v := c1.(type) {
+// Records the conflict, and returns the winner.
+func (m *merger) conflict(path []string, c1, c2 interface{}, childWinsByDefault bool) interface{} {
+	m.conflicts = append(m.conflicts, MergeConflict{
+		Path:    path,
+		Type1:   (&Config{DataSubTree: c1}).Kind(),
+		Type2:   (&Config{DataSubTree: c2}).Kind(),
+		Origin1: m.c1.originAt(path),
+		Origin2: m.c2.originAt(path),
+	})
+	switch m.opts.ConflictPolicy {
+	case ConflictPolicy_ChildWins:
+		m.record(path)
+		return m.resolve(path, c2)
+	case ConflictPolicy_Default:
+		if childWinsByDefault {
+			m.record(path)
+			return c2
+		}
+	}
+	return c1
+}
 
-		case map[string]interface{}:
-			c2v := c2.(map[string]interface{})
+// Records, that the value at the path comes from the c2, unless its parent value already does.
+func (m *merger) record(path []string) {
+	for i := len(path); i >= 0; i-- {
+		if _, ok := m.origins[pathKey(path[:i])]; ok {
+			return
+		}
+	}
+	m.origins[pathKey(path)] = &valueOrigin{path: path, origin: m.c2.originAt(path)}
+}
 
-			for k2, v2 := range c2v {
-				switch v2v := v2.(type) {
-				case map[string]interface{}:
-					if _, ok := c1v[k2]; !ok {
-						c1v[k2] = make(map[string]interface{})
-					}
-					c1v[k2] = extend_v2(c1v[k2], v2v)
-				case []interface{}:
-					if _, ok := c1v[k2]; !ok {
-						c1v[k2] = make([]interface{}, 0)
-					}
-					c1v[k2] = extend_v2(c1v[k2], v2v)
-				default:
-					c1v[k2] = v2
-				}
+// The origin of a value, and of all the values in it, which don't have their own.
+type valueOrigin struct {
+	path   []string // absolute
+	origin string
+}
+
+// Returns the origin of the value at the path: of the config, which set it in the ExtendBy_v2(),
+// or the one of the whole config.
+func (c *Config) originAt(path []string) string {
+	absPath := append(append([]string{}, c.GetCurrentLocationPlusPath()...), path...)
+	for i := len(absPath); i >= 0; i-- {
+		if vo, ok := c.origins[pathKey(absPath[:i])]; ok {
+			return vo.origin
+		}
+	}
+	return c.origin
+}
+
+// Puts the origins of the merged values, by the paths relative to the c, in place of the ones
+// of the values they replaced. The values, which were there before, keep their origin, even if
+// the c.origin is changed later.
+func (c *Config) updateOrigins(merged map[string]*valueOrigin) {
+	if len(merged) == 0 {
+		return
+	}
+	location := c.GetCurrentLocationPlusPath()
+	if c.origins == nil {
+		// shared with the configs, this one is a copy of, same as the data
+		origins := map[string]*valueOrigin{}
+		for p := c; p != nil && p.origins == nil; p = p.parent {
+			p.origins = origins
+		}
+	}
+	if _, ok := c.origins[pathKey(location)]; !ok {
+		c.origins[pathKey(location)] = &valueOrigin{path: location, origin: c.originAt(nil)}
+	}
+	abs := make(map[string]*valueOrigin, len(merged))
+	for _, vo := range merged {
+		absPath := append(append([]string{}, location...), vo.path...)
+		abs[pathKey(absPath)] = &valueOrigin{path: absPath, origin: vo.origin}
+	}
+	for k, vo := range c.origins {
+		for i := len(vo.path); i >= 0; i-- {
+			if _, ok := abs[pathKey(vo.path[:i])]; ok {
+				delete(c.origins, k)
+				break
 			}
+		}
+	}
+	for k, vo := range abs {
+		c.origins[k] = vo
+	}
+}
 
-		case []interface{}:
-			c2v := c2.([]interface{})
+// Merges c2 into c1 at the path, and returns the result. The c1 is modified in place where possible.
+func (m *merger) merge(path []string, c1, c2 interface{}) interface{} {
+	if strategy, v2, idField, ok := m.parseDirective(c2); ok {
+		return m.mergeWith(path, strategy, idField, c1, v2)
+	}
+	strategy, idField := m.strategyFor(path, c2)
+	return m.mergeWith(path, strategy, idField, c1, c2)
+}
 
-			lenDiff := len(c2v) - len(c1v)
-			if lenDiff > 0 {
-				c1v = append(c1v, make([]interface{}, lenDiff)...)
-				c1 = c1v
+func (m *merger) mergeWith(path []string, strategy MergeStrategy, idField string, c1, c2 interface{}) interface{} {
+	switch c2v := c2.(type) {
+
+	case map[string]interface{}:
+		c1v, ok := c1.(map[string]interface{})
+		if c1 == nil || strategy == MergeStrategy_Replace {
+			m.record(path)
+			return m.resolve(path, c2)
+		}
+		if !ok {
+			return m.conflict(path, c1, c2, false)
+		}
+		for k2, v2 := range c2v {
+			if isDeleteMarker(v2) {
+				delete(c1v, k2)
+				continue
+			}
+			c1v[k2] = m.merge(appendPath(path, k2), c1v[k2], v2)
+		}
+		return c1v
+
+	case []interface{}:
+		c1v, ok := c1.([]interface{})
+		if c1 == nil || strategy == MergeStrategy_Replace {
+			m.record(path)
+			return m.resolve(path, c2)
+		}
+		if !ok {
+			return m.conflict(path, c1, c2, false)
+		}
+		switch strategy {
+		case MergeStrategy_Append, MergeStrategy_Prepend:
+			// the items are of both, but the list was last changed by the c2
+			m.record(path)
+		}
+		switch strategy {
+		case MergeStrategy_Append:
+			return append(c1v, m.resolve(path, c2v).([]interface{})...)
+		case MergeStrategy_Prepend:
+			return append(m.resolve(path, c2v).([]interface{}), c1v...)
+		case MergeStrategy_MergeById:
+			return m.mergeById(path, idField, c1v, c2v)
+		}
+		lenDiff := len(c2v) - len(c1v)
+		if lenDiff > 0 {
+			c1v = append(c1v, make([]interface{}, lenDiff)...)
+		}
+		for i2, v2 := range c2v {
+			c1v[i2] = m.merge(appendPath(path, strconv.Itoa(i2)), c1v[i2], v2)
+		}
+		return c1v
+
+	default:
+		switch c1.(type) {
+		case map[string]interface{}, []interface{}:
+			if c2 != nil && strategy != MergeStrategy_Replace {
+				return m.conflict(path, c1, c2, true)
 			}
+		}
+		m.record(path)
+		return c2
+	}
+}
 
-			for i2, v2 := range c2v {
-				switch v2v := v2.(type) {
-				case map[string]interface{}:
-					if c1v[i2] == nil {
-						c1v[i2] = make(map[string]any)
-					}
-					c1v[i2] = extend_v2(c1v[i2], v2v)
-				case []interface{}:
-					if c1v[i2] == nil {
-						c1v[i2] = make([]any, 0)
-					}
-					c1v[i2] = extend_v2(c1v[i2], v2v)
-				default:
-					c1v[i2] = v2
+func (m *merger) mergeById(path []string, idField string, c1v, c2v []interface{}) []interface{} {
+	for _, v2 := range c2v {
+		found := false
+		if id2, ok := itemId(v2, idField); ok {
+			for i1, v1 := range c1v {
+				if id1, ok := itemId(v1, idField); ok && id1 == id2 {
+					c1v[i1] = m.merge(appendPath(path, strconv.Itoa(i1)), v1, v2)
+					found = true
+					break
 				}
 			}
+		}
+		if !found {
+			c1v = append(c1v, m.merge(appendPath(path, strconv.Itoa(len(c1v))), nil, v2))
+		}
+	}
+	return c1v
+}
 
+// Makes a copy of v, with all the directives in it applied.
+func (m *merger) resolve(path []string, v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(vv))
+		for k, x := range vv {
+			if isDeleteMarker(x) {
+				continue
+			}
+			r[k] = m.merge(appendPath(path, k), nil, x)
+		}
+		return r
+	case []interface{}:
+		r := make([]interface{}, 0, len(vv))
+		for i, x := range vv {
+			r = append(r, m.merge(appendPath(path, strconv.Itoa(i)), nil, x))
 		}
+		return r
+	}
+	return v
+}
 
-	} // if reflect.TypeOf(c1) == reflect.TypeOf(c2)
+func (m *merger) strategyFor(path []string, c2 interface{}) (MergeStrategy, string) {
+	for _, rule := range m.opts.Rules {
+		if matchPath(rule.Path, path) {
+			if rule.IdField != "" {
+				return rule.Strategy, rule.IdField
+			}
+			return rule.Strategy, m.opts.IdField
+		}
+	}
+	if _, ok := c2.([]interface{}); ok {
+		return m.opts.ListStrategy, m.opts.IdField
+	}
+	return MergeStrategy_Merge, m.opts.IdField
+}
 
-	return c1
+func (m *merger) parseDirective(v interface{}) (strategy MergeStrategy, value interface{}, idField string, ok bool) {
+	vm, isMap := v.(map[string]interface{})
+	if !isMap {
+		return
+	}
+	idField = m.opts.IdField
+	n := 0
+	for k, x := range vm {
+		if s, isDirective := directiveStrategies[k]; isDirective {
+			strategy, value = s, x
+			n++
+		} else if k == Directive_Id {
+			idField = fmt.Sprint(x)
+		} else {
+			return
+		}
+	}
+	ok = n == 1
+	return
+}
+
+func isDeleteMarker(v interface{}) bool {
+	switch vv := v.(type) {
+	case string:
+		return vv == Directive_Delete
+	case map[string]interface{}:
+		b, ok := vv[Directive_Delete].(bool)
+		return ok && b && len(vv) == 1
+	}
+	return false
+}
+
+func itemId(v interface{}, idField string) (string, bool) {
+	vm, ok := v.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	id, ok := vm[idField]
+	if !ok {
+		return "", false
+	}
+	switch id.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return "", false
+	}
+	return fmt.Sprint(id), true
+}
+
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func appendPath(path []string, part string) []string {
+	p := make([]string, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, part)
 }
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrHelp is returned by FlagBinder.Parse(), if the --help or -h was given; the help is
+// already printed then.
+var ErrHelp = errors.New("help requested")
+
+// FlagBinder parses GNU-style command line options, typed according to the existing config
+// values. Unlike the Flag() and Args(), it doesn't use the flag package. For a value at
+// "server.read-timeout", both --server.read-timeout=5s and --server-read-timeout 5s are
+// accepted. Bool options don't need a value (--verbose), but can have one (--verbose=false).
+// Lists of scalars are set by repeating the option: --hosts a --hosts b. The names take
+// precedence over the aliases; the names or aliases, shared by several values, e.g. of the
+// "a.b-c" and "a-b.c", are ambiguous, and using them is an error.
+type FlagBinder struct {
+	Config    *Config
+	Opts      *FlagBinder_Options
+	options   map[string]*flagOption   // by name and alias
+	ambiguous map[string][]*flagOption // the names and aliases of several options
+	all       []*flagOption            // sorted by name
+}
+
+type FlagBinder_Options struct {
+	Name         string            // program name, for the help
+	Descriptions map[string]string // by dot-path, for the help
+	Output       io.Writer         // where the help goes, os.Stderr by default
+	SingleDash   bool              // also accept -name, as the flag package does
+	// Return the unknown options, and the --help, -h, with the positional arguments, instead
+	// of an error; the values of the unknown options, given as separate args, are positional then.
+	IgnoreUnknown bool
+}
+
+type flagOption struct {
+	path     []string
+	name     string
+	alias    string
+	kind     string
+	itemType interface{}
+	def      interface{}
+}
+
+func NewFlagBinder(c *Config, f ...func(opts *FlagBinder_Options)) (fb *FlagBinder) {
+	opts := &FlagBinder_Options{
+		Name:         os.Args[0],
+		Descriptions: map[string]string{},
+		Output:       os.Stderr,
+	}
+	for _, f := range f {
+		f(opts)
+	}
+
+	fb = &FlagBinder{
+		Config:    c,
+		Opts:      opts,
+		options:   map[string]*flagOption{},
+		ambiguous: map[string][]*flagOption{},
+	}
+	fb.addOptions(nil, c.DataSubTree)
+	sort.SliceStable(fb.all, func(i, j int) bool { return fb.all[i].name < fb.all[j].name })
+	isName := map[string]bool{}
+	for _, o := range fb.all {
+		fb.register(o.name, o)
+		isName[o.name] = true
+	}
+	for _, o := range fb.all {
+		if !isName[o.alias] {
+			fb.register(o.alias, o)
+		}
+	}
+	return
+}
+
+func (fb *FlagBinder) register(name string, o *flagOption) {
+	if others, ok := fb.ambiguous[name]; ok {
+		fb.ambiguous[name] = append(others, o)
+		return
+	}
+	if prev, ok := fb.options[name]; ok {
+		delete(fb.options, name)
+		fb.ambiguous[name] = []*flagOption{prev, o}
+		return
+	}
+	fb.options[name] = o
+}
+
+func (fb *FlagBinder) addOptions(path []string, v interface{}) {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fb.addOptions(appendPath(path, k), vv[k])
+		}
+		return
+	case []interface{}:
+		for _, x := range vv {
+			switch x.(type) {
+			case map[string]interface{}, []interface{}:
+				// not a list of scalars, so address the items one by one
+				for i, x := range vv {
+					fb.addOptions(appendPath(path, strconv.Itoa(i)), x)
+				}
+				return
+			}
+		}
+	}
+	if len(path) == 0 {
+		return
+	}
+	o := &flagOption{
+		path:  path,
+		name:  strings.Join(path, "."),
+		alias: strings.Join(path, "-"),
+		kind:  flagKind(v),
+		def:   v,
+	}
+	if l, ok := v.([]interface{}); ok && len(l) > 0 {
+		o.itemType = l[0]
+		for _, x := range l {
+			if fmt.Sprintf("%T", x) != fmt.Sprintf("%T", l[0]) {
+				o.itemType = nil
+			}
+		}
+	}
+	fb.all = append(fb.all, o)
+}
+
+func flagKind(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		return "bool"
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case []interface{}:
+		return "list"
+	case string:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			if _, err := time.ParseDuration(v); err == nil {
+				return "duration"
+			}
+		}
+	}
+	return "string"
+}
+
+// Parses the args (without the program name), sets the values, and returns the positional
+// arguments. Everything after the "--" is positional, as are the "-" and the negative numbers.
+// Unknown options, including the single-dash ones like -v, are an error, with a suggestion of
+// a known one, if there's a similar one.
+func (fb *FlagBinder) Parse(args []string) (positional []string, err error) {
+	lists := map[string][]interface{}{} // the lists set so far, by the repeated options
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(positional, args[i+1:]...), nil
+		}
+		if arg == "-h" || arg == "--help" {
+			if fb.Opts.IgnoreUnknown {
+				positional = append(positional, arg)
+				continue
+			}
+			fmt.Fprint(fb.Opts.Output, fb.Help())
+			return positional, ErrHelp
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+		if _, err := strconv.ParseFloat(arg, 64); err == nil {
+			positional = append(positional, arg)
+			continue
+		}
+		name, val, hasVal := strings.Cut(strings.TrimPrefix(arg[1:], "-"), "=")
+		if !strings.HasPrefix(arg, "--") && !fb.Opts.SingleDash {
+			if fb.Opts.IgnoreUnknown {
+				positional = append(positional, arg)
+				continue
+			}
+			return positional, fb.unknownOptionError("-", name)
+		}
+		if others, ok := fb.ambiguous[name]; ok {
+			return positional, fb.ambiguousOptionError(name, others)
+		}
+		o, ok := fb.options[name]
+		if !ok {
+			if fb.Opts.IgnoreUnknown {
+				positional = append(positional, arg)
+				continue
+			}
+			return positional, fb.unknownOptionError("--", name)
+		}
+		if !hasVal {
+			if o.kind == "bool" {
+				val = "true"
+			} else if i+1 < len(args) {
+				i++
+				val = args[i]
+			} else {
+				return positional, fmt.Errorf("option --%v needs a value", name)
+			}
+		}
+
+		var v interface{}
+		if o.kind == "list" {
+			item, err := convertEnvValue(o.itemType, val)
+			if err != nil {
+				return positional, fmt.Errorf("option --%v: %v", name, err)
+			}
+			lists[o.name] = append(lists[o.name], item)
+			v = append([]interface{}{}, lists[o.name]...)
+		} else {
+			if v, err = convertEnvValue(o.def, val); err != nil {
+				return positional, fmt.Errorf("option --%v: %v", name, err)
+			}
+			if o.kind == "duration" {
+				if _, err = time.ParseDuration(val); err != nil {
+					return positional, fmt.Errorf("option --%v: %v", name, err)
+				}
+			}
+		}
+		fb.Config.Set(o.path, v)
+	}
+	return positional, nil
+}
+
+func (fb *FlagBinder) unknownOptionError(dashes, name string) error {
+	candidates := make([]string, 0, len(fb.options))
+	for k := range fb.options {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+	if s := suggest(name, candidates); s != "" {
+		return fmt.Errorf("unknown option %v%v, did you mean --%v?", dashes, name, s)
+	}
+	return fmt.Errorf("unknown option %v%v", dashes, name)
+}
+
+func (fb *FlagBinder) ambiguousOptionError(name string, others []*flagOption) error {
+	paths := make([]string, 0, len(others))
+	for _, o := range others {
+		paths = append(paths, fmt.Sprintf("%q", o.path))
+	}
+	return fmt.Errorf("ambiguous option --%v, it's of several values: %v", name, strings.Join(paths, ", "))
+}
+
+// Returns the help text, with all the options, their types, descriptions and defaults.
+func (fb *FlagBinder) Help() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage of %v:\n", fb.Opts.Name)
+	for _, o := range fb.all {
+		name := o.name
+		switch o.kind {
+		case "bool":
+			fmt.Fprintf(&b, "  --%v\n", name)
+		case "list":
+			fmt.Fprintf(&b, "  --%v value (repeatable)\n", name)
+		default:
+			fmt.Fprintf(&b, "  --%v %v\n", name, o.kind)
+		}
+		if d := fb.Opts.Descriptions[name]; d != "" {
+			fmt.Fprintf(&b, "    \t%v\n", d)
+		}
+		fmt.Fprintf(&b, "    \t(default: %v)\n", formatFlagDefault(o.def))
+	}
+	return b.String()
+}
+
+func formatFlagDefault(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		ss := make([]string, 0, len(v))
+		for _, x := range v {
+			ss = append(ss, formatFlagDefault(x))
+		}
+		return "[" + strings.Join(ss, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
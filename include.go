@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// The YAML tag, and the JSON map key, which graft the content of another file at their place.
+// The file name is relative to the including file, e.g.
+//
+//	tls: !include tls.yaml
+//	"tls": {"$include": "tls.yaml"}
+const (
+	Tag_Include       = "!include"
+	Directive_Include = "$include"
+)
+
+var ErrIncludeCycle = errors.New("config file include cycle")
+
+// Resolves includes, and keeps the chain of files being included, to detect cycles.
+type includer struct {
+	fsys  fs.FS // if nil, the OS file system is used
+	chain []string
+}
+
+func (inc *includer) readFile(fileName string) ([]byte, error) {
+	if inc.fsys != nil {
+		return fs.ReadFile(inc.fsys, filepath.ToSlash(fileName))
+	}
+	return os.ReadFile(fileName)
+}
+
+func (inc *includer) join(dir, fileName string) string {
+	if inc.fsys != nil {
+		return path.Join(filepath.ToSlash(dir), fileName)
+	}
+	if filepath.IsAbs(fileName) {
+		return fileName
+	}
+	return filepath.Join(dir, fileName)
+}
+
+// Reads and parses the included file, and returns its data.
+func (inc *includer) include(dir, fileName string) (interface{}, error) {
+	fileName = inc.join(dir, fileName)
+	chain := append(append(make([]string, 0, len(inc.chain)+1), inc.chain...), fileName)
+	for _, f := range inc.chain {
+		if f == fileName {
+			return nil, &IncludeError{FileName: fileName, Chain: chain, Err: ErrIncludeCycle}
+		}
+	}
+	inc.chain = chain
+	defer func() { inc.chain = chain[:len(chain)-1] }()
+
+	var c *Config
+	var err error
+	switch {
+	case reSuffixYaml.MatchString(fileName):
+		c, err = parseYamlFile(inc, fileName)
+	case reSuffixJson.MatchString(fileName):
+		c, err = parseJsonFile(inc, fileName)
+	default:
+		err = errors.New("unknown file suffix")
+	}
+	if err != nil {
+		var ie *IncludeError
+		if errors.As(err, &ie) {
+			return nil, err
+		}
+		return nil, &IncludeError{FileName: fileName, Chain: chain, Err: err}
+	}
+	return c.DataSubTree, nil
+}
+
+// Replaces the nodes tagged with the !include with the content of the included files.
+func (inc *includer) resolveYamlTags(dir string, n *yaml.Node) error {
+	if n.Tag == Tag_Include {
+		if n.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %v: %v expects a file name", n.Line, Tag_Include)
+		}
+		v, err := inc.include(dir, n.Value)
+		if err != nil {
+			return err
+		}
+		return n.Encode(v)
+	}
+	for _, child := range n.Content {
+		if err := inc.resolveYamlTags(dir, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replaces the {"$include": "file"} maps with the content of the included files.
+func (inc *includer) resolveDirectives(dir string, v interface{}) (interface{}, error) {
+	var err error
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		if fileName, ok := vv[Directive_Include].(string); ok && len(vv) == 1 {
+			return inc.include(dir, fileName)
+		}
+		for k, x := range vv {
+			if vv[k], err = inc.resolveDirectives(dir, x); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, x := range vv {
+			if vv[i], err = inc.resolveDirectives(dir, x); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"regexp"
 
@@ -11,10 +14,20 @@ import (
 
 type InitContext struct {
 	FileName string
+	Dir      string
 	Data     []byte
+	Args     []string
+	FS       fs.FS // if set, the files are read from it, instead of the OS file system
+	DotEnv   bool  // the file or data is in the .env format, regardless of the suffix
 	Logger   *zerolog.Logger
 	ErrPtr   *error
 	OkPtr    *bool
+
+	ExtendOptions []func(opts *ExtendBy_Options)
+	Interpolation bool
+	Migrations    *Migrations // applied to every loaded file, with the warnings logged to the Logger
+
+	RemainingArgs []string // set by Load(), with FromArgs()
 }
 
 func (ic *InitContext) FromFile(fileName string) *InitContext {
@@ -22,16 +35,54 @@ func (ic *InitContext) FromFile(fileName string) *InitContext {
 	return ic
 }
 
+// Loads a .env file, as a flat map of variables, to be used with the ExtendByDotEnv_WithPrefix().
+// Files with the .env suffix are recognized by FromFile() as well.
+func (ic *InitContext) FromDotEnv(fileName string) *InitContext {
+	ic.FileName = fileName
+	ic.DotEnv = true
+	return ic
+}
+
+// Loads all the YAML and JSON files in the directory, in the lexical order of their names,
+// each next one extending the previous ones, with the ExtendOptions.
+func (ic *InitContext) FromDir(dir string) *InitContext {
+	ic.Dir = dir
+	return ic
+}
+
 func (ic *InitContext) FromBytes(data []byte) *InitContext {
 	ic.Data = data
 	return ic
 }
 
+func (ic *InitContext) WithFS(fsys fs.FS) *InitContext {
+	ic.FS = fsys
+	return ic
+}
+
 func (ic *InitContext) WithLogger(logger *zerolog.Logger) *InitContext {
 	ic.Logger = logger
 	return ic
 }
 
+// Sets the merge options, used when a config is extended by another one, e.g. with parenting.
+func (ic *InitContext) WithExtendOptions(f ...func(opts *ExtendBy_Options)) *InitContext {
+	ic.ExtendOptions = f
+	return ic
+}
+
+// Makes the Load() and LoadWithParenting() do the Interpolate() on the loaded config.
+func (ic *InitContext) WithInterpolation() *InitContext {
+	ic.Interpolation = true
+	return ic
+}
+
+// Makes the Load() and LoadWithParenting() apply the migrations to every loaded file.
+func (ic *InitContext) WithMigrations(m *Migrations) *InitContext {
+	ic.Migrations = m
+	return ic
+}
+
 func (ic *InitContext) Err(err *error) *InitContext {
 	ic.ErrPtr = err
 	return ic
@@ -44,37 +95,58 @@ func (ic *InitContext) Ok(ok *bool) *InitContext {
 
 var reSuffixYaml = regexp.MustCompile(`\.[Yy][Aa]?[Mm][Ll]\s*$`)
 var reSuffixJson = regexp.MustCompile(`\.(JSON|json)\s*$`)
+var reSuffixDotEnv = regexp.MustCompile(`\.env\s*$`)
 
 func (ic *InitContext) Load() *Config {
 	var c *Config
 	var err error
+	inc := &includer{fsys: ic.FS}
 
 	func() {
 		switch {
+		case ic.Args != nil:
+			c, err = ic.loadArgs()
+			return
+
+		case len(ic.Data) > 0 && ic.DotEnv:
+			c, err = parseDotEnv(ic.Data)
+			return
+
 		case len(ic.Data) > 0:
 			// c, err = parseSerk(ic.Data)
 			// if err == nil {
 			// 	return
 			// }
-			c, err = parseYaml(ic.Data)
-			if err == nil {
+			c, err = parseYaml(inc, ".", ic.Data)
+			var syntaxErr *yamlSyntaxError
+			if err == nil || !errors.As(err, &syntaxErr) {
+				// a bad include is reported as is
 				return
 			}
-			c, err = parseJson(ic.Data)
+			c, err = parseJson(inc, ".", ic.Data)
 			return
 
 		case len(ic.FileName) > 0:
+			inc.chain = []string{filepath.Clean(ic.FileName)}
 			switch {
+			case ic.DotEnv || reSuffixDotEnv.MatchString(ic.FileName):
+				c, err = parseDotEnvFile(inc, ic.FileName)
+				return
 			case reSuffixYaml.MatchString(ic.FileName) == true:
-				c, err = parseYamlFile(ic.FileName)
+				c, err = parseYamlFile(inc, ic.FileName)
 				return
 			case reSuffixJson.MatchString(ic.FileName) == true:
-				c, err = parseJsonFile(ic.FileName)
+				c, err = parseJsonFile(inc, ic.FileName)
 				return
 			default:
 				err = errors.New("unknown file suffix")
 				return
 			}
+
+		case len(ic.Dir) > 0:
+			c, err = ic.loadDir()
+			return
+
 		default:
 			err = errors.New("data or file not specified")
 			return
@@ -82,45 +154,119 @@ func (ic *InitContext) Load() *Config {
 	}()
 
 	if err != nil {
-		if ic.ErrPtr != nil {
-			*ic.ErrPtr = err
-		}
-		if ic.OkPtr != nil {
-			*ic.OkPtr = false
-		}
-		if ic.ErrPtr == nil && ic.OkPtr == nil {
-			panic(err)
-		}
+		ic.handleError(err)
 		return nil
 	}
 
 	// this does inherit these..
 	c.ErrPtr = ic.ErrPtr
 	c.OkPtr = ic.OkPtr
+	if c.origin == "" {
+		c.origin = ic.FileName
+	}
+	c.fsys = ic.FS
+
+	// with the args, the files were already migrated one by one
+	if ic.Migrations != nil && ic.Args == nil {
+		logger := ic.Logger
+		if logger == nil {
+			logger = &log.Logger
+		}
+		if err = ic.Migrations.apply(c, logger); err != nil {
+			ic.handleError(err)
+			return nil
+		}
+	}
+
+	if ic.Interpolation {
+		c.Interpolate()
+	}
 
 	return c
 }
 
+func (ic *InitContext) loadDir() (*Config, error) {
+	var entries []fs.DirEntry
+	var err error
+	if ic.FS != nil {
+		entries, err = fs.ReadDir(ic.FS, filepath.ToSlash(ic.Dir))
+	} else {
+		entries, err = os.ReadDir(ic.Dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{DataSubTree: map[string]interface{}{}, origin: ic.Dir}
+	for _, e := range entries { // these are sorted by name
+		if e.IsDir() || !(reSuffixYaml.MatchString(e.Name()) || reSuffixJson.MatchString(e.Name())) {
+			continue
+		}
+		fileName := filepath.Join(ic.Dir, e.Name())
+		var err error
+		c2 := (&InitContext{FileName: fileName, FS: ic.FS}).Err(&err).Load()
+		if err != nil {
+			return nil, fmt.Errorf("config file %q: %w", fileName, err)
+		}
+		conflicts, err := c.ExtendBy_v2_Strict(c2, ic.ExtendOptions...)
+		if ic.Logger != nil {
+			for _, mc := range conflicts {
+				ic.Logger.Warn().Msgf("Xo7dRkc: merge conflict at %v", mc)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("config file %q: %w", fileName, err)
+		}
+	}
+	return c, nil
+}
+
+// Sets Err, if it's present; sets Ok=false, if it's present;
+// Then panics, unless there is present either of Ok or Err.
+func (ic *InitContext) handleError(err error) {
+	if ic.ErrPtr != nil {
+		*ic.ErrPtr = err
+	}
+	if ic.OkPtr != nil {
+		*ic.OkPtr = false
+	}
+	if ic.ErrPtr == nil && ic.OkPtr == nil {
+		panic(err)
+	}
+}
+
+// Loads the config file, and then recursively all its parents, specified by the "parent" and "parents"
+// keys, relative to the directory of the file which refers to them. Parents are applied in order, and
+// then the child extends the aggregated parent. The same file may be included several times through
+// different branches (a diamond), but a file which includes itself, directly or indirectly, is an error.
+// On failure, a *ParentingError is reported via the Err/Ok, same as with Load().
 func (ic *InitContext) LoadWithParenting() (result *Config) {
 	if ic.Logger == nil {
 		ic.Logger = &log.Logger
 	}
 	ic.Logger.Info().Msgf("ziPdTJw: reading the config file(s)...")
-	filesAlreadyRead := map[string]bool{}
 	isRoot := true
 	depth := 0
-	var readParent func(baseDir, configFileName string) *Config
-	readParent = func(baseDir, currConfigFileName string) *Config {
+	extend := func(logger zerolog.Logger, c1, c2 *Config) error {
+		conflicts, err := c1.ExtendBy_v2_Strict(c2, ic.ExtendOptions...)
+		for _, mc := range conflicts {
+			logger.Warn().Msgf("Hq3vNmX: merge conflict at %v", mc)
+		}
+		return err
+	}
+	var readParent func(chain []string) (*Config, error)
+	readParent = func(chain []string) (*Config, error) {
 		depth--
 		defer func() { depth++ }()
+		currConfigFileName := chain[len(chain)-1]
+		baseDir := filepath.Dir(currConfigFileName)
 		logger := ic.Logger.With().Int("depth", depth).Logger()
 		logger.Info().Msgf("EZWLkX: reading the config file '%v'...", currConfigFileName)
-		filesAlreadyRead[currConfigFileName] = true
 		var err error
-		conf := (&InitContext{FileName: currConfigFileName}).Err(&err).Load()
+		conf := (&InitContext{FileName: currConfigFileName, FS: ic.FS, Logger: &logger, Migrations: ic.Migrations}).
+			Err(&err).Load()
 		if err != nil {
-			logger.Err(err).Msgf("fYmNdkUt: config.ParseYamlFile('%v') failed", currConfigFileName)
-			panic(err)
+			logger.Err(err).Msgf("fYmNdkUt: loading the config file '%v' failed", currConfigFileName)
+			return nil, &ParentingError{FileName: currConfigFileName, Chain: chain, Err: err}
 		}
 		if isRoot {
 			isRoot = false
@@ -129,38 +275,62 @@ func (ic *InitContext) LoadWithParenting() (result *Config) {
 		}
 		parents := []string{}
 		ok := true
-		p1 := conf.Ok(&ok).P("parent").String()
+		p1 := conf.Err(nil).Ok(&ok).P("parent").String()
 		if ok {
 			parents = append(parents, p1)
 		}
-		list := conf.P("parents").ListString()
+		list := conf.Err(nil).U().P("parents").ListString()
 		parents = append(parents, list...)
 		var aggregatedParentConf *Config
 		for _, parentConfigFileName := range parents {
-			parentFullPath := baseDir + "/" + parentConfigFileName
-			if filesAlreadyRead[parentFullPath] {
-				logger.Err(err).Msgf("AweL9D: config file loop: the file '%v' already read", parentFullPath)
-				panic(err)
+			parentFullPath := filepath.Join(baseDir, parentConfigFileName)
+			parentChain := append(append(make([]string, 0, len(chain)+1), chain...), parentFullPath)
+			for _, f := range chain {
+				if filepath.Clean(f) == parentFullPath {
+					err = &ParentingError{FileName: parentFullPath, Chain: parentChain, Err: ErrParentingCycle}
+					logger.Err(err).Msgf("AweL9D: config file loop: the file '%v' includes itself", parentFullPath)
+					return nil, err
+				}
+			}
+			confParent, err := readParent(parentChain)
+			if err != nil {
+				return nil, err
 			}
-			confParent := readParent(filepath.Dir(parentFullPath), parentFullPath)
 			if aggregatedParentConf == nil {
 				logger.Info().Msgf("KUY76-1: set aggregated parent from '%v'", parentFullPath)
 				aggregatedParentConf = confParent
 			} else {
 				logger.Info().Msgf("KUY76-2: extend aggregated parent with '%v'", parentFullPath)
-				aggregatedParentConf.ExtendBy_v2(confParent)
+				if err = extend(logger, aggregatedParentConf, confParent); err != nil {
+					return nil, &ParentingError{FileName: parentFullPath, Chain: parentChain, Err: err}
+				}
 			}
 		}
 		if aggregatedParentConf != nil {
 			logger.Info().Msgf("KUY76-3: extend aggregated parent with '%v' and return it", currConfigFileName)
-			aggregatedParentConf.ExtendBy_v2(conf)
-			conf = aggregatedParentConf
+		} else {
+			// no parents, but the directives still need to be applied
+			aggregatedParentConf = &Config{}
+		}
+		if err = extend(logger, aggregatedParentConf, conf); err != nil {
+			return nil, &ParentingError{FileName: currConfigFileName, Chain: chain, Err: err}
 		}
-		return conf
+		aggregatedParentConf.origin = currConfigFileName
+		return aggregatedParentConf, nil
 	}
-	result = readParent(filepath.Dir(ic.FileName), ic.FileName)
+	result, err := readParent([]string{ic.FileName})
+	if err != nil {
+		ic.handleError(err)
+		return nil
+	}
+	result.ErrPtr = ic.ErrPtr
+	result.OkPtr = ic.OkPtr
+	result.fsys = ic.FS
 	result.Set([]string{"parent"}, nil)
 	result.Set([]string{"parents"}, nil)
+	if ic.Interpolation {
+		result.Interpolate()
+	}
 	ic.Logger.Info().Msg("K2aUDgz: reading the config file(s) OK")
 	return
 }
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/rusriver/config/v2/deepcopy"
+)
+
+// Interpolate() replaces the references in the string values of the config:
+//
+//	${a.b.c}             another value of this config, the path is relative to the current location
+//	${env:VAR}           the env variable, it's an error if it isn't set
+//	${env:VAR:-default}  the env variable, or the default if it isn't set
+//	${file:/path}        the file content, without trailing newlines
+//	$${...}              the literal ${...}
+//
+// If a string consists of a single config value reference, the referenced value is used
+// as is, with its type; otherwise, it's embedded into the string. Referenced values are
+// interpolated first, and the reference cycles are detected. The original templates are
+// still available with Raw(), and the next Interpolate() starts from them again, unless the
+// values were since changed, so it can be called any number of times. The files are read
+// from the InitContext.FS, if the config was loaded with it.
+func (c *Config) Interpolate() *Config {
+	ip := &interpolator{
+		root:      c.DataSubTree,
+		rootPath:  c.GetCurrentLocationPlusPath(),
+		resolving: map[string]bool{},
+		done:      map[string]bool{},
+		templates: c.templates,
+		fsys:      c.fsys,
+	}
+	if ip.templates == nil {
+		ip.templates = map[string]*template{}
+	}
+	ip.restoreTemplates()
+	_, err := ip.resolveAt(nil)
+	c.DataSubTree = ip.root
+	c.templates = ip.templates
+	if err != nil {
+		c.handleError(err)
+	}
+	return c
+}
+
+// Returns the value at the current location, as it was before the Interpolate(). If the value
+// was since changed, or it wasn't a template, returns the current value.
+func (c *Config) Raw() interface{} {
+	if t, ok := c.templates[pathKey(c.GetCurrentLocationPlusPath())]; ok {
+		if reflect.DeepEqual(t.resolved, c.DataSubTree) {
+			return t.raw
+		}
+	}
+	return c.DataSubTree
+}
+
+type template struct {
+	path     []string // absolute
+	raw      string
+	resolved interface{}
+}
+
+type interpolator struct {
+	root      interface{}
+	rootPath  []string
+	resolving map[string]bool
+	done      map[string]bool
+	templates map[string]*template
+	fsys      fs.FS
+}
+
+// Puts back the raw templates under the root, where the resolved values are still unchanged.
+func (ip *interpolator) restoreTemplates() {
+	for _, t := range ip.templates {
+		if !isPathPrefix(ip.rootPath, t.path) {
+			continue
+		}
+		path := t.path[len(ip.rootPath):]
+		v, err := goByExactPath(ip.root, path)
+		if err != nil || !reflect.DeepEqual(v, t.resolved) {
+			continue
+		}
+		if len(path) == 0 {
+			ip.root = t.raw
+		} else {
+			set(ip.root, path, t.raw)
+		}
+	}
+}
+
+func pathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// Resolves all the references in the value at the path, stores, and returns it.
+func (ip *interpolator) resolveAt(path []string) (interface{}, error) {
+	v, err := goByPath(ip.root, path)
+	if err != nil {
+		return nil, err
+	}
+	key := pathKey(path)
+	if ip.done[key] {
+		return v, nil
+	}
+	if ip.resolving[key] {
+		return nil, fmt.Errorf("Interpolation cycle at %q", strings.Join(path, "."))
+	}
+	ip.resolving[key] = true
+	defer delete(ip.resolving, key)
+
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k := range vv {
+			if _, err = ip.resolveAt(appendPath(path, k)); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i := range vv {
+			if _, err = ip.resolveAt(appendPath(path, fmt.Sprint(i))); err != nil {
+				return nil, err
+			}
+		}
+	case string:
+		if !strings.Contains(vv, "${") {
+			break
+		}
+		if v, err = ip.expand(vv); err != nil {
+			return nil, fmt.Errorf("Interpolation failed at %q: %w", strings.Join(path, "."), err)
+		}
+		if len(path) == 0 {
+			ip.root = v
+		} else if err = set(ip.root, path, v); err != nil {
+			return nil, err
+		}
+		absPath := append(append([]string{}, ip.rootPath...), path...)
+		ip.templates[pathKey(absPath)] = &template{path: absPath, raw: vv, resolved: v}
+	}
+	ip.done[key] = true
+	return v, nil
+}
+
+func (ip *interpolator) expand(s string) (interface{}, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated reference in %q", s)
+		}
+		v, err := ip.lookup(s[i+2 : i+end])
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 && end == len(s)-1 {
+			return v, nil
+		}
+		switch v := v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("can't embed %T into the string %q", v, s)
+		case nil:
+		default:
+			b.WriteString(fmt.Sprint(v))
+		}
+		i += end + 1
+	}
+	return b.String(), nil
+}
+
+func (ip *interpolator) lookup(expr string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(expr, "env:"):
+		name, def, hasDef := strings.Cut(expr[len("env:"):], ":-")
+		if v, ok := os.LookupEnv(name); ok {
+			return v, nil
+		}
+		if hasDef {
+			return def, nil
+		}
+		return nil, fmt.Errorf("env variable %q is not set", name)
+	case strings.HasPrefix(expr, "file:"):
+		b, err := (&includer{fsys: ip.fsys}).readFile(expr[len("file:"):])
+		if err != nil {
+			return nil, err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	default:
+		v, err := ip.resolveAt(SplitPathToParts(expr))
+		if err != nil {
+			return nil, err
+		}
+		return deepcopy.Copy(v), nil
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"sort"
+)
+
+// Kind is the kind of a value in the config tree.
+type Kind string
+
+const (
+	Kind_Map     Kind = "map"
+	Kind_List    Kind = "list"
+	Kind_String  Kind = "string"
+	Kind_Number  Kind = "number"
+	Kind_Bool    Kind = "bool"
+	Kind_Null    Kind = "null"
+	Kind_Invalid Kind = "invalid" // not a normalized value, see InitContext
+)
+
+// SkipSubtree is returned by the Walk() callback, to skip the children of the current node.
+var SkipSubtree = errors.New("skip this subtree")
+
+// Tells whether the path exists, relative to the current location. Never fails.
+func (c *Config) Has(pathParts ...string) bool {
+	_, err := goByPath(c.DataSubTree, pathParts)
+	return err == nil
+}
+
+// Returns the kind of the value at the current location.
+func (c *Config) Kind() Kind {
+	switch c.DataSubTree.(type) {
+	case map[string]interface{}:
+		return Kind_Map
+	case []interface{}:
+		return Kind_List
+	case string:
+		return Kind_String
+	case int, float64:
+		return Kind_Number
+	case bool:
+		return Kind_Bool
+	case nil:
+		return Kind_Null
+	}
+	return Kind_Invalid
+}
+
+// Returns the sorted keys of the map at the current location, or nil, if it's not a map.
+func (c *Config) Keys() []string {
+	m, ok := c.DataSubTree.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns the number of the map keys or list items at the current location, or 0 for scalars.
+func (c *Config) Len() int {
+	switch v := c.DataSubTree.(type) {
+	case map[string]interface{}:
+		return len(v)
+	case []interface{}:
+		return len(v)
+	}
+	return 0
+}
+
+// Walk() calls the f for the current location and all the nodes below it, in pre-order, with
+// the map keys sorted. The path is relative to the current location, as used with P(), and the
+// node has its location set, as after P(). If the f returns SkipSubtree, the children of the
+// node are skipped; other errors stop the walk, and are returned.
+func (c *Config) Walk(f func(path []string, node *Config) error) error {
+	return walkTree(c.DataSubTree, nil, func(path []string, v interface{}) error {
+		c2 := c.ChildCopy()
+		c2.DataSubTree = v
+		c2.relativePathFromParent = path
+		return f(path, c2)
+	})
+}
@@ -2,20 +2,20 @@ package config
 
 import (
 	"encoding/json"
-	"os"
+	"path/filepath"
 )
 
 // parseJsonFile reads a JSON configuration from the given filename.
-func parseJsonFile(filename string) (*Config, error) {
-	c, err := os.ReadFile(filename)
+func parseJsonFile(inc *includer, filename string) (*Config, error) {
+	c, err := inc.readFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return parseJson(c)
+	return parseJson(inc, filepath.Dir(filename), c)
 }
 
-// parseJson performs the real JSON parsing.
-func parseJson(c []byte) (*Config, error) {
+// parseJson performs the real JSON parsing. Includes are resolved relative to the dir.
+func parseJson(inc *includer, dir string, c []byte) (*Config, error) {
 	var out interface{}
 	var err error
 	if err = json.Unmarshal(c, &out); err != nil {
@@ -24,6 +24,9 @@ func parseJson(c []byte) (*Config, error) {
 	if out, err = normalizeValue(out); err != nil {
 		return nil, err
 	}
+	if out, err = inc.resolveDirectives(dir, out); err != nil {
+		return nil, err
+	}
 	return &Config{DataSubTree: out}, nil
 }
 
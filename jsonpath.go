@@ -0,0 +1,564 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// JSONPath() returns the values matching the JSONPath expression, in the document order, with
+// their locations set, as with Query(). The "$" is the current location. Supported are:
+//
+//	$.a.b, $['a']['b']         child keys, also in bracket notation
+//	$.a[0], $.a[-1]            list items, negative ones count from the end
+//	$.a.*, $.a[*]              every map value or list item
+//	$..b, $..*, $..[0]         the descendants, at any depth
+//	$['a','b'], $.a[0,2]       unions
+//	$.a[1:3], $.a[::2]         slices, [start:end:step], with positive steps
+//	$.a[?(@.b == 'x' && @.c > 2 || !@.d)]
+//	                           filters, with ==, !=, <, <=, >, >=, &&, ||, !, parentheses,
+//	                           and the existence tests; the @ is the item, and the $ is the root
+//
+// E.g. c.JSONPath("$.clusters[?(@.region=='eu')].nodes[*].addr"). An invalid expression is
+// reported via Err/Ok, as the *JSONPathError.
+func (c *Config) JSONPath(expr string) []*Config {
+	p := &jsonPathParser{expr: expr}
+	selectors, err := p.parse()
+	if err != nil {
+		c.handleError(err)
+		return nil
+	}
+	nodes := []queryMatch{{path: []string{}, v: c.DataSubTree}}
+	for _, sel := range selectors {
+		var next []queryMatch
+		for _, n := range nodes {
+			targets := []queryMatch{n}
+			if sel.descendant {
+				targets = jsonPathDescendants(n, nil)
+			}
+			for _, t := range targets {
+				next = append(next, sel.apply(t, c.DataSubTree)...)
+			}
+		}
+		nodes = next
+	}
+	result := make([]*Config, 0, len(nodes))
+	for _, n := range nodes {
+		c2 := c.ChildCopy()
+		c2.DataSubTree = n.v
+		c2.relativePathFromParent = n.path
+		c2.recordPath(true)
+		result = append(result, c2)
+	}
+	return result
+}
+
+// Returns the node and all its descendants, in the document order.
+func jsonPathDescendants(n queryMatch, out []queryMatch) []queryMatch {
+	out = append(out, n)
+	for _, ch := range queryChildren(n.v) {
+		out = jsonPathDescendants(queryMatch{path: appendPath(n.path, ch.path[0]), v: ch.v}, out)
+	}
+	return out
+}
+
+type jsonPathSelector struct {
+	descendant bool
+	wildcard   bool
+	union      []jsonPathUnionItem
+	slice      *jsonPathSlice
+	filter     jsonPathExpr
+}
+
+type jsonPathUnionItem struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+type jsonPathSlice struct {
+	start, end *int
+	step       int
+}
+
+func (sel *jsonPathSelector) apply(n queryMatch, root interface{}) (out []queryMatch) {
+	child := func(key string, v interface{}) {
+		out = append(out, queryMatch{path: appendPath(n.path, key), v: v})
+	}
+	switch {
+	case sel.wildcard:
+		for _, ch := range queryChildren(n.v) {
+			child(ch.path[0], ch.v)
+		}
+	case sel.filter != nil:
+		for _, ch := range queryChildren(n.v) {
+			if jsonPathTruthy(sel.filter.eval(ch.v, root)) {
+				child(ch.path[0], ch.v)
+			}
+		}
+	case sel.slice != nil:
+		l, ok := n.v.([]interface{})
+		if !ok {
+			return
+		}
+		bound := func(b *int, def int) int {
+			if b == nil {
+				return def
+			}
+			i := *b
+			if i < 0 {
+				i += len(l)
+			}
+			if i < 0 {
+				i = 0
+			}
+			if i > len(l) {
+				i = len(l)
+			}
+			return i
+		}
+		for i := bound(sel.slice.start, 0); i < bound(sel.slice.end, len(l)); i += sel.slice.step {
+			child(strconv.Itoa(i), l[i])
+		}
+	default:
+		for _, item := range sel.union {
+			switch vv := n.v.(type) {
+			case map[string]interface{}:
+				if x, ok := vv[item.name]; ok && !item.isIndex {
+					child(item.name, x)
+				}
+			case []interface{}:
+				i := item.index
+				if i < 0 {
+					i += len(vv)
+				}
+				if item.isIndex && i >= 0 && i < len(vv) {
+					child(strconv.Itoa(i), vv[i])
+				}
+			}
+		}
+	}
+	return
+}
+
+// jsonPathExpr is a node of a filter expression. The eval returns the value, and whether it exists.
+type jsonPathExpr interface {
+	eval(current, root interface{}) (interface{}, bool)
+}
+
+type jsonPathLiteral struct {
+	v interface{}
+}
+
+func (e *jsonPathLiteral) eval(current, root interface{}) (interface{}, bool) {
+	return e.v, true
+}
+
+type jsonPathQuery struct {
+	fromRoot bool
+	path     []string
+}
+
+func (e *jsonPathQuery) eval(current, root interface{}) (interface{}, bool) {
+	from := current
+	if e.fromRoot {
+		from = root
+	}
+	v := from
+	for _, part := range e.path {
+		// the negative indices count from the end, as in the selectors
+		if l, ok := v.([]interface{}); ok {
+			if i, err := strconv.Atoi(part); err == nil && i < 0 {
+				part = strconv.Itoa(i + len(l))
+			}
+		}
+		next, err := goByPath(v, []string{part})
+		if err != nil {
+			return nil, false
+		}
+		v = next
+	}
+	return v, true
+}
+
+type jsonPathBinary struct {
+	op          string
+	left, right jsonPathExpr
+}
+
+func (e *jsonPathBinary) eval(current, root interface{}) (interface{}, bool) {
+	switch e.op {
+	case "&&":
+		return jsonPathTruthy(e.left.eval(current, root)) && jsonPathTruthy(e.right.eval(current, root)), true
+	case "||":
+		return jsonPathTruthy(e.left.eval(current, root)) || jsonPathTruthy(e.right.eval(current, root)), true
+	}
+	a, aOk := e.left.eval(current, root)
+	b, bOk := e.right.eval(current, root)
+	if !aOk || !bOk {
+		// nothing equals to the missing value, but another missing one
+		switch e.op {
+		case "==":
+			return !aOk && !bOk, true
+		case "!=":
+			return aOk || bOk, true
+		}
+		return false, true
+	}
+	return compareValues(a, e.op, b), true
+}
+
+type jsonPathNot struct {
+	e jsonPathExpr
+}
+
+func (e *jsonPathNot) eval(current, root interface{}) (interface{}, bool) {
+	return !jsonPathTruthy(e.e.eval(current, root)), true
+}
+
+// The queries are true if they exist, and the results of the operators are bools.
+func jsonPathTruthy(v interface{}, exists bool) bool {
+	if b, ok := v.(bool); ok {
+		return exists && b
+	}
+	return exists
+}
+
+type jsonPathParser struct {
+	expr string
+	pos  int
+}
+
+func (p *jsonPathParser) errorf(format string, args ...interface{}) error {
+	return &JSONPathError{Expr: p.expr, Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *jsonPathParser) skipSpaces() {
+	for p.pos < len(p.expr) && unicode.IsSpace(rune(p.expr[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *jsonPathParser) peek(s string) bool {
+	return strings.HasPrefix(p.expr[p.pos:], s)
+}
+
+func (p *jsonPathParser) consume(s string) bool {
+	p.skipSpaces()
+	if p.peek(s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jsonPathParser) parse() ([]*jsonPathSelector, error) {
+	if !p.consume("$") {
+		return nil, p.errorf("expected '$'")
+	}
+	selectors := []*jsonPathSelector{}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.expr) {
+			return selectors, nil
+		}
+		sel, err := p.parseSelector(false)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+	}
+}
+
+// Parses .name, .*, ..name, ..*, ..[...], or [...]. In the filters, only the simple
+// child selectors are allowed.
+func (p *jsonPathParser) parseSelector(simple bool) (*jsonPathSelector, error) {
+	sel := &jsonPathSelector{}
+	switch {
+	case p.peek(".."):
+		if simple {
+			return nil, p.errorf("'..' is not supported in the filters")
+		}
+		p.pos += 2
+		sel.descendant = true
+		if p.peek("[") {
+			return p.parseBracket(sel, simple)
+		}
+	case p.peek("."):
+		p.pos++
+	case p.peek("["):
+		return p.parseBracket(sel, simple)
+	default:
+		return nil, p.errorf("expected '.' or '['")
+	}
+	if p.peek("*") {
+		if simple {
+			return nil, p.errorf("'*' is not supported in the filters")
+		}
+		p.pos++
+		sel.wildcard = true
+		return sel, nil
+	}
+	name := p.parseName()
+	if name == "" {
+		return nil, p.errorf("expected a name")
+	}
+	sel.union = []jsonPathUnionItem{{name: name}}
+	return sel, nil
+}
+
+func (p *jsonPathParser) parseName() string {
+	start := p.pos
+	for _, r := range p.expr[p.pos:] {
+		if !(r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			break
+		}
+		p.pos += len(string(r))
+	}
+	return p.expr[start:p.pos]
+}
+
+func (p *jsonPathParser) parseBracket(sel *jsonPathSelector, simple bool) (*jsonPathSelector, error) {
+	p.pos++ // [
+	switch {
+	case p.consume("?"):
+		if simple {
+			return nil, p.errorf("nested filters are not supported")
+		}
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		sel.filter = filter
+	case p.consume("*"):
+		if simple {
+			return nil, p.errorf("'*' is not supported in the filters")
+		}
+		sel.wildcard = true
+	default:
+		for {
+			p.skipSpaces()
+			if p.peek("'") || p.peek(`"`) {
+				s, err := p.parseString()
+				if err != nil {
+					return nil, err
+				}
+				sel.union = append(sel.union, jsonPathUnionItem{name: s})
+			} else {
+				start, hasStart, err := p.parseInt()
+				if err != nil {
+					return nil, err
+				}
+				if p.consume(":") {
+					if len(sel.union) > 0 || simple {
+						return nil, p.errorf("slices can't be in unions, nor in the filters")
+					}
+					return p.parseSlice(sel, start, hasStart)
+				}
+				if !hasStart {
+					return nil, p.errorf("expected a string, a number, or a slice")
+				}
+				sel.union = append(sel.union, jsonPathUnionItem{index: start, isIndex: true})
+			}
+			if !p.consume(",") {
+				break
+			}
+			if simple {
+				return nil, p.errorf("unions are not supported in the filters")
+			}
+		}
+	}
+	if !p.consume("]") {
+		return nil, p.errorf("expected ']'")
+	}
+	return sel, nil
+}
+
+func (p *jsonPathParser) parseSlice(sel *jsonPathSelector, start int, hasStart bool) (*jsonPathSelector, error) {
+	sel.slice = &jsonPathSlice{step: 1}
+	if hasStart {
+		sel.slice.start = &start
+	}
+	end, hasEnd, err := p.parseInt()
+	if err != nil {
+		return nil, err
+	}
+	if hasEnd {
+		sel.slice.end = &end
+	}
+	if p.consume(":") {
+		step, hasStep, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		if hasStep {
+			if step <= 0 {
+				return nil, p.errorf("only positive slice steps are supported")
+			}
+			sel.slice.step = step
+		}
+	}
+	if !p.consume("]") {
+		return nil, p.errorf("expected ']'")
+	}
+	return sel, nil
+}
+
+// Parses an optional integer.
+func (p *jsonPathParser) parseInt() (int, bool, error) {
+	p.skipSpaces()
+	start := p.pos
+	if p.peek("-") {
+		p.pos++
+	}
+	for p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, false, nil
+	}
+	i, err := strconv.Atoi(p.expr[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false, p.errorf("invalid number")
+	}
+	return i, true, nil
+}
+
+func (p *jsonPathParser) parseString() (string, error) {
+	quote := p.expr[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.expr) {
+		ch := p.expr[p.pos]
+		p.pos++
+		switch {
+		case ch == quote:
+			return b.String(), nil
+		case ch == '\\' && p.pos < len(p.expr):
+			b.WriteByte(p.expr[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *jsonPathParser) parseOr() (jsonPathExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &jsonPathBinary{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *jsonPathParser) parseAnd() (jsonPathExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &jsonPathBinary{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *jsonPathParser) parseUnary() (jsonPathExpr, error) {
+	if p.consume("!") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathNot{e: e}, nil
+	}
+	if p.consume("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		return e, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *jsonPathParser) parseComparison() (jsonPathExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range queryFilterOps {
+		if p.consume(op) {
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return &jsonPathBinary{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *jsonPathParser) parseOperand() (jsonPathExpr, error) {
+	p.skipSpaces()
+	switch {
+	case p.peek("@") || p.peek("$"):
+		q := &jsonPathQuery{fromRoot: p.peek("$"), path: []string{}}
+		p.pos++
+		for p.peek(".") || p.peek("[") {
+			sel, err := p.parseSelector(true)
+			if err != nil {
+				return nil, err
+			}
+			item := sel.union[0]
+			if item.isIndex {
+				item.name = strconv.Itoa(item.index)
+			}
+			q.path = append(q.path, item.name)
+		}
+		return q, nil
+	case p.peek("'") || p.peek(`"`):
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathLiteral{v: s}, nil
+	case p.peek("true"):
+		p.pos += len("true")
+		return &jsonPathLiteral{v: true}, nil
+	case p.peek("false"):
+		p.pos += len("false")
+		return &jsonPathLiteral{v: false}, nil
+	case p.peek("null"):
+		p.pos += len("null")
+		return &jsonPathLiteral{v: nil}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && strings.IndexByte("+-0123456789.eE", p.expr[p.pos]) >= 0 {
+		p.pos++
+	}
+	s := p.expr[start:p.pos]
+	if i, err := strconv.Atoi(s); err == nil {
+		return &jsonPathLiteral{v: i}, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return &jsonPathLiteral{v: f}, nil
+	}
+	p.pos = start
+	return nil, p.errorf("expected '@', '$', or a literal")
+}
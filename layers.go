@@ -0,0 +1,307 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/rusriver/config/v2/deepcopy"
+)
+
+// Layers builds the config from several sources, in the declared order of precedence:
+// each next layer overrides the previous ones. E.g.:
+//
+//	conf, report := (&config.Layers{}).
+//		Defaults(map[string]any{"port": 80}).
+//		FileWithParenting("app.yaml").
+//		Dir("conf.d").
+//		DotEnv(".env", config.DefaultEnvMapper("app")).
+//		Env(config.DefaultEnvMapper("app")).
+//		Args(os.Args...).
+//		Set([]string{"version"}, version).
+//		Err(&err).
+//		Load()
+//
+// The defaults, files, directories and the Data() layers are merged with ExtendBy_v2(),
+// and can add new keys; the env, dotenv and args layers can only override existing ones.
+type Layers struct {
+	FS            fs.FS
+	Logger        *zerolog.Logger
+	ExtendOptions []func(opts *ExtendBy_Options)
+	Interpolation bool // do the Interpolate() on the final config
+	ErrPtr        *error
+	OkPtr         *bool
+
+	layers []*layer
+}
+
+type layer struct {
+	name    string
+	data    func() (*Config, error)
+	overlay func(c *Config) error
+}
+
+// LayerError is reported by Layers.Load(), if any of layers fails.
+type LayerError struct {
+	Layer string
+	Err   error
+}
+
+func (e *LayerError) Error() string {
+	return fmt.Sprintf("config layer %q: %v", e.Layer, e.Err)
+}
+
+func (e *LayerError) Unwrap() error {
+	return e.Err
+}
+
+func (l *Layers) Err(err *error) *Layers {
+	l.ErrPtr = err
+	return l
+}
+
+func (l *Layers) Ok(ok *bool) *Layers {
+	l.OkPtr = ok
+	return l
+}
+
+// Adds a layer of arbitrary data, a tree of maps and lists, or a *Config.
+func (l *Layers) Data(name string, tree interface{}) *Layers {
+	l.layers = append(l.layers, &layer{
+		name: name,
+		data: func() (*Config, error) {
+			if c, ok := tree.(*Config); ok {
+				return c, nil
+			}
+			v, err := normalizeValue(tree)
+			return &Config{DataSubTree: v}, err
+		},
+	})
+	return l
+}
+
+func (l *Layers) Defaults(tree interface{}) *Layers {
+	return l.Data("defaults", tree)
+}
+
+func (l *Layers) File(fileName string) *Layers {
+	return l.load(fileName, func(ic *InitContext) *Config { return ic.FromFile(fileName).Load() })
+}
+
+func (l *Layers) FileWithParenting(fileName string) *Layers {
+	return l.load(fileName, func(ic *InitContext) *Config { return ic.FromFile(fileName).LoadWithParenting() })
+}
+
+func (l *Layers) Dir(dir string) *Layers {
+	return l.load(dir, func(ic *InitContext) *Config { return ic.FromDir(dir).Load() })
+}
+
+func (l *Layers) load(name string, f func(ic *InitContext) *Config) *Layers {
+	l.layers = append(l.layers, &layer{
+		name: name,
+		data: func() (*Config, error) {
+			var err error
+			ic := &InitContext{FS: l.FS, Logger: l.Logger, ExtendOptions: l.ExtendOptions}
+			c := f(ic.Err(&err))
+			return c, err
+		},
+	})
+	return l
+}
+
+// Adds the .env file layer. A missing file is not an error, as .env files are local by nature.
+func (l *Layers) DotEnv(fileName string, m *EnvMapper) *Layers {
+	l.layers = append(l.layers, &layer{
+		name: fileName,
+		overlay: func(c *Config) error {
+			var err error
+			dotEnv := (&InitContext{FS: l.FS}).FromDotEnv(fileName).Err(&err).Load()
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			c.Err(&err).ExtendByDotEnv_WithMapper(dotEnv, m)
+			return err
+		},
+	})
+	return l
+}
+
+// Adds the process environment layer.
+func (l *Layers) Env(m *EnvMapper) *Layers {
+	l.layers = append(l.layers, &layer{
+		name: "env",
+		overlay: func(c *Config) error {
+			var err error
+			c.Err(&err).ExtendByEnvs_WithMapper(m)
+			return err
+		},
+	})
+	return l
+}
+
+// Adds the command line arguments layer, parsed with the FlagBinder, so that the values keep
+// the types of the ones they override, e.g. --verbose, --server.port=80, --log-level debug;
+// the single-dash ones, like -verbose=true, are accepted too, as by the Args(). The args[0] is
+// the program name; the positional arguments, the unknown options and the --help are ignored,
+// they are for the application to handle.
+func (l *Layers) Args(args ...string) *Layers {
+	l.layers = append(l.layers, &layer{
+		name: "args",
+		overlay: func(c *Config) error {
+			if len(args) <= 1 {
+				return nil
+			}
+			fb := NewFlagBinder(c, func(opts *FlagBinder_Options) {
+				opts.Name = args[0]
+				opts.SingleDash = true
+				opts.IgnoreUnknown = true
+			})
+			_, err := fb.Parse(args[1:])
+			return err
+		},
+	})
+	return l
+}
+
+// Adds an in-code override; unlike the env and args, it can create new keys.
+func (l *Layers) Set(pathParts []string, v interface{}) *Layers {
+	l.layers = append(l.layers, &layer{
+		name: "set " + strings.Join(pathParts, "."),
+		overlay: func(c *Config) error {
+			return set(c.DataSubTree, pathParts, v)
+		},
+	})
+	return l
+}
+
+// Builds the config from all the layers, and reports which layer each value came from.
+func (l *Layers) Load() (*Config, *LayersReport) {
+	if l.Logger == nil {
+		l.Logger = &log.Logger
+	}
+	c := &Config{DataSubTree: map[string]interface{}{}}
+	report := &LayersReport{entries: map[string]*LayersReportEntry{}}
+	for _, layer := range l.layers {
+		before := deepcopy.Copy(c.DataSubTree)
+		var err error
+		var layerPaths [][]string
+		if layer.data != nil {
+			var c2 *Config
+			if c2, err = layer.data(); err == nil {
+				layerPaths = getAllPaths((&Config{}).ExtendBy_v2(c2, l.ExtendOptions...).DataSubTree)
+				var conflicts []MergeConflict
+				conflicts, err = c.ExtendBy_v2_Strict(c2, l.ExtendOptions...)
+				for _, mc := range conflicts {
+					l.Logger.Warn().Msgf("pR5uXz2: config layer '%v': merge conflict at %v", layer.name, mc)
+				}
+			}
+		} else {
+			err = layer.overlay(c)
+		}
+		if err != nil {
+			l.handleError(&LayerError{Layer: layer.name, Err: err})
+			return nil, nil
+		}
+		for _, pathParts := range layerPaths {
+			report.add(pathParts, layer.name)
+		}
+		for _, pathParts := range getAllPaths(c.DataSubTree) {
+			v, err := goByPath(before, pathParts)
+			if err != nil || !reflect.DeepEqual(v, mustGoByPath(c.DataSubTree, pathParts)) {
+				report.add(pathParts, layer.name)
+			}
+		}
+	}
+	report.prune(c.DataSubTree)
+
+	c.ErrPtr = l.ErrPtr
+	c.OkPtr = l.OkPtr
+	c.fsys = l.FS
+	if l.Interpolation {
+		c.Interpolate()
+	}
+	return c, report
+}
+
+func (l *Layers) handleError(err error) {
+	(&InitContext{ErrPtr: l.ErrPtr, OkPtr: l.OkPtr}).handleError(err)
+}
+
+func mustGoByPath(c interface{}, pathParts []string) interface{} {
+	v, _ := goByPath(c, pathParts)
+	return v
+}
+
+// LayersReport tells which layer each leaf value of the config came from.
+type LayersReport struct {
+	entries map[string]*LayersReportEntry
+}
+
+type LayersReportEntry struct {
+	Path  []string
+	Layer string
+}
+
+func (r *LayersReport) add(pathParts []string, layer string) {
+	r.entries[pathKey(pathParts)] = &LayersReportEntry{Path: pathParts, Layer: layer}
+}
+
+// Removes the entries for paths which are no longer leaves in the tree.
+func (r *LayersReport) prune(tree interface{}) {
+	for k, e := range r.entries {
+		v, err := goByPath(tree, e.Path)
+		switch v := v.(type) {
+		case map[string]interface{}:
+			err = errNotLeaf(len(v))
+		case []interface{}:
+			err = errNotLeaf(len(v))
+		}
+		if err != nil {
+			delete(r.entries, k)
+		}
+	}
+}
+
+// Empty maps and lists are leaves too.
+func errNotLeaf(n int) error {
+	if n > 0 {
+		return errors.New("not a leaf")
+	}
+	return nil
+}
+
+// Returns the name of the layer, which the value at the path came from, or "" if there's none.
+func (r *LayersReport) Winner(pathParts ...string) string {
+	if e, ok := r.entries[pathKey(pathParts)]; ok {
+		return e.Layer
+	}
+	return ""
+}
+
+// Returns all the entries, sorted by path.
+func (r *LayersReport) Entries() []LayersReportEntry {
+	entries := make([]LayersReportEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return pathKey(entries[i].Path) < pathKey(entries[j].Path)
+	})
+	return entries
+}
+
+func (r *LayersReport) String() string {
+	var b strings.Builder
+	for _, e := range r.Entries() {
+		fmt.Fprintf(&b, "%v: %v\n", strings.Join(e.Path, "."), e.Layer)
+	}
+	return b.String()
+}
@@ -6,11 +6,16 @@ import (
 )
 
 func (c *Config) List(defaultValueFunc ...func() []any) []any {
+	defer c.recordAccess("[]any", c.ExpressionStatus)
+	return c.list(defaultValueFunc...)
+}
+
+func (c *Config) list(defaultValueFunc ...func() []any) []any {
 	n := c.DataSubTree
 	if value, ok := n.([]interface{}); ok {
 		return value
 	}
-	c.handleError(typeMismatchError("[]interface{}", n))
+	c.handleError(c.located(typeMismatchError("[]interface{}", n)))
 	if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 		if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 			panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -23,12 +28,14 @@ func (c *Config) List(defaultValueFunc ...func() []any) []any {
 }
 
 func (c *Config) ListConfig() []*Config {
-	l := c.List()
+	defer c.recordAccess("[]*Config", c.ExpressionStatus)
+	l := c.list()
 
 	l2 := make([]*Config, 0, len(l))
-	for _, v := range l {
+	for i, v := range l {
 		c2 := c.ChildCopy()
 		c2.DataSubTree = v
+		c2.relativePathFromParent = []string{strconv.Itoa(i)}
 		l2 = append(l2, c2)
 	}
 
@@ -36,7 +43,8 @@ func (c *Config) ListConfig() []*Config {
 }
 
 func (c *Config) ListFloat64(defaultValueFunc ...func() []float64) []float64 {
-	l := c.List()
+	defer c.recordAccess("[]float64", c.ExpressionStatus)
+	l := c.list()
 	undef := make([]float64, 0)
 
 	l2 := make([]float64, 0, len(l))
@@ -51,7 +59,7 @@ func (c *Config) ListFloat64(defaultValueFunc ...func() []float64) []float64 {
 		case string:
 			i, err := strconv.ParseFloat(n, 64)
 			if err != nil {
-				c.handleError(err)
+				c.handleError(c.located(conversionError("float64", n, err)))
 				if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 					if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 						panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -64,7 +72,7 @@ func (c *Config) ListFloat64(defaultValueFunc ...func() []float64) []float64 {
 			}
 			v = i
 		default:
-			c.handleError(typeMismatchError("float64, int or string", n))
+			c.handleError(c.located(typeMismatchError("float64, int or string", n)))
 			if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 				if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 					panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -81,7 +89,8 @@ func (c *Config) ListFloat64(defaultValueFunc ...func() []float64) []float64 {
 }
 
 func (c *Config) ListInt(defaultValueFunc ...func() []int) []int {
-	l := c.List()
+	defer c.recordAccess("[]int", c.ExpressionStatus)
+	l := c.list()
 	undef := make([]int, 0)
 
 	l2 := make([]int, 0, len(l))
@@ -93,7 +102,7 @@ func (c *Config) ListInt(defaultValueFunc ...func() []int) []int {
 			if i := int(n); float64(i) == n {
 				v = i
 			} else {
-				c.handleError(fmt.Errorf("Value can't be converted to int: %v", n))
+				c.handleError(c.located(conversionError("int", n, fmt.Errorf("Value can't be converted to int: %v", n))))
 				return undef
 			}
 		case int:
@@ -101,7 +110,7 @@ func (c *Config) ListInt(defaultValueFunc ...func() []int) []int {
 		case string:
 			i, err := strconv.ParseInt(n, 10, 0)
 			if err != nil {
-				c.handleError(err)
+				c.handleError(c.located(conversionError("int", n, err)))
 				if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 					if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 						panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -114,7 +123,7 @@ func (c *Config) ListInt(defaultValueFunc ...func() []int) []int {
 			}
 			v = int(i)
 		default:
-			c.handleError(typeMismatchError("float64, int or string", n))
+			c.handleError(c.located(typeMismatchError("float64, int or string", n)))
 			if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 				if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 					panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -131,6 +140,7 @@ func (c *Config) ListInt(defaultValueFunc ...func() []int) []int {
 }
 
 func (c *Config) ListString(defaultValueFunc ...func() []string) []string {
+	defer c.recordAccess("[]string", c.ExpressionStatus)
 	if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 		if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 			panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -139,7 +149,7 @@ func (c *Config) ListString(defaultValueFunc ...func() []string) []string {
 		return defaultValueFunc[0]()
 	}
 
-	l := c.List()
+	l := c.list()
 
 	l2 := make([]string, 0, len(l))
 	for _, n := range l {
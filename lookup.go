@@ -0,0 +1,228 @@
+package config
+
+import "time"
+
+// Value is the result of Lookup(). Unlike the Err/Ok, its accessors report the failures in their
+// own return values, so there's nothing shared between the expressions, and no ErrOk() to forget:
+//
+//	port, ok := conf.Lookup("server", "port").Int()
+//	timeout, err := conf.Lookup("server", "timeout").TryDuration()
+//
+// The conversions are the same as of the Config accessors, and the errors are the same typed
+// path errors. Both APIs can be used on the same Config.
+type Value struct {
+	c   *Config
+	err error
+}
+
+// Traverses the config down the path, same as P(), but never panics, nor touches the Err, Ok
+// or ErrCollector of the config.
+func (c *Config) Lookup(pathParts ...string) *Value {
+	var err error
+	c2 := c.ChildCopy()
+	c2.ErrPtr = &err
+	c2.OkPtr = nil
+	c2.errCollector = nil
+	c2.collectedErr = nil
+	c2.ExpressionStatus = ExpressionStatus_0_Norm
+	c2 = c2.P(pathParts...)
+	return &Value{c: c2, err: err}
+}
+
+// Traverses further down the path.
+func (v *Value) Lookup(pathParts ...string) *Value {
+	if v.err != nil {
+		return v
+	}
+	return v.c.Lookup(pathParts...)
+}
+
+// Tells whether the path exists.
+func (v *Value) Exists() bool {
+	return v.err == nil
+}
+
+// Returns the error of the path traversal, if any.
+func (v *Value) Err() error {
+	return v.err
+}
+
+// Returns the value as is, a tree of maps and lists, or a scalar.
+func (v *Value) Raw() (interface{}, bool) {
+	if v.err != nil {
+		return nil, false
+	}
+	return v.c.DataSubTree, true
+}
+
+// Runs the accessor on a fresh expression, with its own error.
+func (v *Value) try(f func(c *Config)) error {
+	if v.err != nil {
+		return v.err
+	}
+	var err error
+	c := v.c.ChildCopy()
+	c.ErrPtr = &err
+	f(c)
+	return err
+}
+
+func (v *Value) TryBool() (b bool, err error) {
+	err = v.try(func(c *Config) { b = c.Bool() })
+	return
+}
+
+func (v *Value) TryInt() (i int, err error) {
+	err = v.try(func(c *Config) { i = c.Int() })
+	return
+}
+
+func (v *Value) TryFloat64() (f float64, err error) {
+	err = v.try(func(c *Config) { f = c.Float64() })
+	return
+}
+
+func (v *Value) TryString() (s string, err error) {
+	err = v.try(func(c *Config) { s = c.String() })
+	return
+}
+
+func (v *Value) TryDuration() (d time.Duration, err error) {
+	err = v.try(func(c *Config) { d = c.Duration() })
+	return
+}
+
+func (v *Value) TryList() (l []any, err error) {
+	err = v.try(func(c *Config) { l = c.List() })
+	return
+}
+
+func (v *Value) TryListString() (l []string, err error) {
+	err = v.try(func(c *Config) { l = c.ListString() })
+	return
+}
+
+func (v *Value) TryListInt() (l []int, err error) {
+	err = v.try(func(c *Config) { l = c.ListInt() })
+	return
+}
+
+func (v *Value) TryListFloat64() (l []float64, err error) {
+	err = v.try(func(c *Config) { l = c.ListFloat64() })
+	return
+}
+
+func (v *Value) TryListDuration() (l []time.Duration, err error) {
+	err = v.try(func(c *Config) { l = c.ListDuration() })
+	return
+}
+
+func (v *Value) TryMap() (m map[string]any, err error) {
+	err = v.try(func(c *Config) { m = c.Map() })
+	return
+}
+
+func (v *Value) TryMapString() (m map[string]string, err error) {
+	err = v.try(func(c *Config) { m = c.MapString() })
+	return
+}
+
+func (v *Value) TryMapInt() (m map[string]int, err error) {
+	err = v.try(func(c *Config) { m = c.MapInt() })
+	return
+}
+
+func (v *Value) TryMapFloat64() (m map[string]float64, err error) {
+	err = v.try(func(c *Config) { m = c.MapFloat64() })
+	return
+}
+
+func (v *Value) TryMapBool() (m map[string]bool, err error) {
+	err = v.try(func(c *Config) { m = c.MapBool() })
+	return
+}
+
+func (v *Value) TryMapDuration() (m map[string]time.Duration, err error) {
+	err = v.try(func(c *Config) { m = c.MapDuration() })
+	return
+}
+
+func (v *Value) Bool() (bool, bool) {
+	b, err := v.TryBool()
+	return b, err == nil
+}
+
+func (v *Value) Int() (int, bool) {
+	i, err := v.TryInt()
+	return i, err == nil
+}
+
+func (v *Value) Float64() (float64, bool) {
+	f, err := v.TryFloat64()
+	return f, err == nil
+}
+
+func (v *Value) String() (string, bool) {
+	s, err := v.TryString()
+	return s, err == nil
+}
+
+func (v *Value) Duration() (time.Duration, bool) {
+	d, err := v.TryDuration()
+	return d, err == nil
+}
+
+func (v *Value) List() ([]any, bool) {
+	l, err := v.TryList()
+	return l, err == nil
+}
+
+func (v *Value) ListString() ([]string, bool) {
+	l, err := v.TryListString()
+	return l, err == nil
+}
+
+func (v *Value) ListInt() ([]int, bool) {
+	l, err := v.TryListInt()
+	return l, err == nil
+}
+
+func (v *Value) ListFloat64() ([]float64, bool) {
+	l, err := v.TryListFloat64()
+	return l, err == nil
+}
+
+func (v *Value) ListDuration() ([]time.Duration, bool) {
+	l, err := v.TryListDuration()
+	return l, err == nil
+}
+
+func (v *Value) Map() (map[string]any, bool) {
+	m, err := v.TryMap()
+	return m, err == nil
+}
+
+func (v *Value) MapString() (map[string]string, bool) {
+	m, err := v.TryMapString()
+	return m, err == nil
+}
+
+func (v *Value) MapInt() (map[string]int, bool) {
+	m, err := v.TryMapInt()
+	return m, err == nil
+}
+
+func (v *Value) MapFloat64() (map[string]float64, bool) {
+	m, err := v.TryMapFloat64()
+	return m, err == nil
+}
+
+func (v *Value) MapBool() (map[string]bool, bool) {
+	m, err := v.TryMapBool()
+	return m, err == nil
+}
+
+func (v *Value) MapDuration() (map[string]time.Duration, bool) {
+	m, err := v.TryMapDuration()
+	return m, err == nil
+}
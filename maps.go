@@ -6,11 +6,16 @@ import (
 )
 
 func (c *Config) Map(defaultValueFunc ...func() map[string]any) map[string]any {
+	defer c.recordAccess("map[string]any", c.ExpressionStatus)
+	return c.mapAny(defaultValueFunc...)
+}
+
+func (c *Config) mapAny(defaultValueFunc ...func() map[string]any) map[string]any {
 	n := c.DataSubTree
 	if value, ok := n.(map[string]interface{}); ok {
 		return value
 	}
-	c.handleError(typeMismatchError("map[string]interface{}", n))
+	c.handleError(c.located(typeMismatchError("map[string]interface{}", n)))
 	if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 		if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 			panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -23,19 +28,22 @@ func (c *Config) Map(defaultValueFunc ...func() map[string]any) map[string]any {
 }
 
 func (c *Config) MapConfig() map[string]*Config {
-	m := c.Map()
+	defer c.recordAccess("map[string]*Config", c.ExpressionStatus)
+	m := c.mapAny()
 
 	m2 := make(map[string]*Config, len(m))
 	for k, v := range m {
 		m2[k] = c.ChildCopy()
 		m2[k].DataSubTree = v
+		m2[k].relativePathFromParent = []string{k}
 	}
 
 	return m2
 }
 
 func (c *Config) MapFloat64(defaultValueFunc ...func() map[string]float64) map[string]float64 {
-	m := c.Map()
+	defer c.recordAccess("map[string]float64", c.ExpressionStatus)
+	m := c.mapAny()
 	undef := make(map[string]float64)
 
 	m2 := make(map[string]float64, len(m))
@@ -50,7 +58,7 @@ func (c *Config) MapFloat64(defaultValueFunc ...func() map[string]float64) map[s
 		case string:
 			i, err := strconv.ParseFloat(n, 64)
 			if err != nil {
-				c.handleError(err)
+				c.handleError(c.located(conversionError("float64", n, err)))
 				if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 					if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 						panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -63,7 +71,7 @@ func (c *Config) MapFloat64(defaultValueFunc ...func() map[string]float64) map[s
 			}
 			v = i
 		default:
-			c.handleError(typeMismatchError("float64, int or string", n))
+			c.handleError(c.located(typeMismatchError("float64, int or string", n)))
 			if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 				if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 					panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -80,7 +88,8 @@ func (c *Config) MapFloat64(defaultValueFunc ...func() map[string]float64) map[s
 }
 
 func (c *Config) MapInt(defaultValueFunc ...func() map[string]int) map[string]int {
-	m := c.Map()
+	defer c.recordAccess("map[string]int", c.ExpressionStatus)
+	m := c.mapAny()
 	undef := make(map[string]int)
 
 	m2 := make(map[string]int, len(m))
@@ -92,7 +101,7 @@ func (c *Config) MapInt(defaultValueFunc ...func() map[string]int) map[string]in
 			if i := int(n); float64(i) == n {
 				v = i
 			} else {
-				c.handleError(fmt.Errorf("Value can't be converted to int: %v", n))
+				c.handleError(c.located(conversionError("int", n, fmt.Errorf("Value can't be converted to int: %v", n))))
 				if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 					if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 						panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -108,7 +117,7 @@ func (c *Config) MapInt(defaultValueFunc ...func() map[string]int) map[string]in
 		case string:
 			i, err := strconv.ParseInt(n, 10, 0)
 			if err != nil {
-				c.handleError(err)
+				c.handleError(c.located(conversionError("int", n, err)))
 				if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 					if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 						panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -121,7 +130,7 @@ func (c *Config) MapInt(defaultValueFunc ...func() map[string]int) map[string]in
 			}
 			v = int(i)
 		default:
-			c.handleError(typeMismatchError("float64, int or string", n))
+			c.handleError(c.located(typeMismatchError("float64, int or string", n)))
 			if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 				if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 					panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -138,6 +147,7 @@ func (c *Config) MapInt(defaultValueFunc ...func() map[string]int) map[string]in
 }
 
 func (c *Config) MapString(defaultValueFunc ...func() map[string]string) map[string]string {
+	defer c.recordAccess("map[string]string", c.ExpressionStatus)
 	if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 		if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 			panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -146,7 +156,7 @@ func (c *Config) MapString(defaultValueFunc ...func() map[string]string) map[str
 		return defaultValueFunc[0]()
 	}
 
-	m := c.Map()
+	m := c.mapAny()
 
 	m2 := make(map[string]string, len(m))
 	for k, n := range m {
@@ -164,6 +174,7 @@ func (c *Config) MapString(defaultValueFunc ...func() map[string]string) map[str
 }
 
 func (c *Config) MapBool(defaultValueFunc ...func() map[string]bool) map[string]bool {
+	defer c.recordAccess("map[string]bool", c.ExpressionStatus)
 	if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 		if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 			panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -172,7 +183,7 @@ func (c *Config) MapBool(defaultValueFunc ...func() map[string]bool) map[string]
 		return defaultValueFunc[0]()
 	}
 
-	m := c.Map()
+	m := c.mapAny()
 	undef := make(map[string]bool)
 
 	m2 := make(map[string]bool, len(m))
@@ -182,7 +193,7 @@ func (c *Config) MapBool(defaultValueFunc ...func() map[string]bool) map[string]
 		case bool:
 			v = n
 		default:
-			c.handleError(typeMismatchError("bool", n))
+			c.handleError(c.located(typeMismatchError("bool", n)))
 			if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 				if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 					panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const DefaultVersionKey = "config-version"
+
+// Migration moves the value from an old path to a new one, optionally transforming it. The
+// paths may have "*" parts, matching any map key or list index; the To gets them substituted
+// in order, e.g. From "servers.*.addr" To "servers.*.address". With no To, the value stays
+// where it is, and is only transformed, if there's a Transform.
+type Migration struct {
+	From        []string
+	To          []string
+	Transform   func(v interface{}) (interface{}, error)
+	Deprecation string // if set, a warning is logged for every key found
+}
+
+// Migrations is a registry of Migration rules. The versioned ones are chained: a config with
+// the "config-version: 1" gets the rules of the version 1, then of the version 2, and so on, and
+// its version is set to the latest one then. A config without the version is of the version 0.
+// The unversioned rules are always applied, after the versioned ones.
+type Migrations struct {
+	VersionKey string // DefaultVersionKey, if empty
+	Rules      []*Migration
+
+	versions map[int][]*Migration
+}
+
+func NewMigrations() *Migrations {
+	return &Migrations{VersionKey: DefaultVersionKey}
+}
+
+// Adds the unversioned rules.
+func (m *Migrations) Add(rules ...*Migration) *Migrations {
+	m.Rules = append(m.Rules, rules...)
+	return m
+}
+
+// Adds the rules, which migrate the config from the version to the next one.
+func (m *Migrations) AddVersion(version int, rules ...*Migration) *Migrations {
+	if m.versions == nil {
+		m.versions = map[int][]*Migration{}
+	}
+	m.versions[version] = append(m.versions[version], rules...)
+	return m
+}
+
+// Applies the migrations to the config at the current location. Deprecated keys are reported
+// to the logger, or to the global one, if it's nil.
+func (c *Config) Migrate(m *Migrations, logger *zerolog.Logger) *Config {
+	if logger == nil {
+		logger = &log.Logger
+	}
+	if err := m.apply(c, logger); err != nil {
+		c.handleError(err)
+	}
+	return c
+}
+
+func (m *Migrations) apply(c *Config, logger *zerolog.Logger) error {
+	versionKey := m.VersionKey
+	if versionKey == "" {
+		versionKey = DefaultVersionKey
+	}
+	if len(m.versions) > 0 {
+		version, err := m.version(c.DataSubTree, versionKey)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(m.versions))
+		for v := range m.versions {
+			versions = append(versions, v)
+		}
+		sort.Ints(versions)
+		latest := versions[len(versions)-1] + 1
+		if version < latest {
+			for _, v := range versions {
+				if v < version {
+					continue
+				}
+				logger.Info().Msgf("Vb8rNe2: migrating the config '%v' from the version %v", c.origin, v)
+				for _, rule := range m.versions[v] {
+					if err = rule.apply(c, logger); err != nil {
+						return err
+					}
+				}
+			}
+			if err = set(c.DataSubTree, []string{versionKey}, latest); err != nil {
+				return err
+			}
+		}
+	}
+	for _, rule := range m.Rules {
+		if err := rule.apply(c, logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrations) version(tree interface{}, versionKey string) (int, error) {
+	v, err := goByPath(tree, []string{versionKey})
+	if err != nil {
+		return 0, nil
+	}
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid %v: %v", versionKey, v)
+}
+
+func (rule *Migration) apply(c *Config, logger *zerolog.Logger) error {
+	for _, from := range expandPathPattern(c.DataSubTree, rule.From, nil) {
+		v, _ := goByPath(c.DataSubTree, from)
+		var err error
+		if rule.Transform != nil {
+			if v, err = rule.Transform(v); err != nil {
+				return fmt.Errorf("Migrating %q: %w", strings.Join(from, "."), err)
+			}
+		}
+		to := from
+		if rule.To != nil {
+			to = substitutePathPattern(rule.To, rule.From, from)
+		}
+		if rule.Deprecation != "" {
+			logger.Warn().Msgf("Dq5tLm7: config '%v': the key %q is deprecated: %v",
+				c.origin, strings.Join(from, "."), rule.Deprecation)
+		}
+		if pathKey(to) == pathKey(from) {
+			if rule.Transform != nil {
+				if err = set(c.DataSubTree, to, v); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if _, err = goByPath(c.DataSubTree, to); err == nil {
+			logger.Warn().Msgf("Rj2wYc6: config '%v': both %q and %q are set, the former is ignored",
+				c.origin, strings.Join(from, "."), strings.Join(to, "."))
+		} else if err = set(c.DataSubTree, to, v); err != nil {
+			return err
+		}
+		if err = deleteByPath(c.DataSubTree, from); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the existing paths, matching the pattern with the "*" parts.
+func expandPathPattern(tree interface{}, pattern []string, base []string) (paths [][]string) {
+	if len(pattern) == 0 {
+		return [][]string{base}
+	}
+	part := pattern[0]
+	if part != "*" {
+		v, err := goByPath(tree, []string{part})
+		if err != nil {
+			return nil
+		}
+		return expandPathPattern(v, pattern[1:], appendPath(base, part))
+	}
+	switch tree := tree.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(tree))
+		for k := range tree {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			paths = append(paths, expandPathPattern(tree[k], pattern[1:], appendPath(base, k))...)
+		}
+	case []interface{}:
+		// backwards, so that deleting the items one by one doesn't shift the rest
+		for i := len(tree) - 1; i >= 0; i-- {
+			paths = append(paths, expandPathPattern(tree[i], pattern[1:], appendPath(base, strconv.Itoa(i)))...)
+		}
+	}
+	return paths
+}
+
+// Substitutes the "*" parts of the target pattern with the parts of the path, which were matched
+// by the "*" parts of the source pattern, in order.
+func substitutePathPattern(target, source, path []string) []string {
+	matched := []string{}
+	for i, part := range source {
+		if part == "*" {
+			matched = append(matched, path[i])
+		}
+	}
+	result := make([]string, 0, len(target))
+	for _, part := range target {
+		if part == "*" && len(matched) > 0 {
+			part, matched = matched[0], matched[1:]
+		}
+		result = append(result, part)
+	}
+	return result
+}
@@ -0,0 +1,358 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/rusriver/config/v2/deepcopy"
+)
+
+// Applies the RFC 6902 JSON Patch to the config at the current location, so that the paths of
+// the patch are relative to it. The operations are add, remove, replace, move, copy and test.
+// The patch is atomic: if any operation fails, the error is reported via Err/Ok, as the
+// *PatchError, and nothing is changed.
+//
+// With the Source, the patch is run as a single command by the updater, so the readers never see
+// it applied partially; the call waits for it to be applied. Without the Source, the config is
+// patched in place, same as with NonThreadSafe_Set().
+func (c *Config) ApplyPatch(patchJSON []byte) {
+	patch, err := parseJSONPatch(patchJSON)
+	if err != nil {
+		c.handleError(c.located(err))
+		return
+	}
+	c.applyPatch(patch)
+}
+
+// Applies the RFC 6902 JSON Patch, same as ApplyPatch(), but ignoring the Source.
+func (c *Config) NonThreadSafe_ApplyPatch(patchJSON []byte) {
+	patch, err := parseJSONPatch(patchJSON)
+	if err != nil {
+		c.handleError(c.located(err))
+		return
+	}
+	c.nonThreadSafe_ApplyPatch(patch)
+}
+
+// treePatch is a parsed patch, either the JSON Patch or the JSON Merge Patch.
+type treePatch interface {
+	// Applies the patch to a deep copy of the doc, and returns the patched copy.
+	apply(doc interface{}) (interface{}, error)
+}
+
+func (c *Config) applyPatch(patch treePatch) {
+	if c.Source == nil {
+		c.nonThreadSafe_ApplyPatch(patch)
+		return
+	}
+	loc := c.GetCurrentLocationPlusPath()
+	msg := &MsgCmd{
+		Command:  Command_Patch,
+		FullPath: loc,
+		V:        patch,
+		ChErr:    make(chan error, 1),
+	}
+	ctx := c.Source.Opts.Context
+	select {
+	case c.Source.ChCmd <- msg:
+	case <-ctx.Done():
+		c.handleError(ctx.Err())
+		return
+	}
+	select {
+	case c.Source.ChFlushSignal <- &MsgFlushSignal{}:
+	case <-ctx.Done():
+		c.handleError(ctx.Err())
+		return
+	}
+	var err error
+	select {
+	case err = <-msg.ChErr:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		c.handleError(withLocation(err, loc))
+	}
+}
+
+func (c *Config) nonThreadSafe_ApplyPatch(patch treePatch) {
+	v, err := patch.apply(c.DataSubTree)
+	if err != nil {
+		c.handleError(c.located(err))
+		return
+	}
+	if err = c.replaceDataSubTree(v); err != nil {
+		c.handleError(c.located(err))
+	}
+}
+
+// Puts the v in place of the current value, so that it's seen via the parent configs too.
+func (c *Config) replaceDataSubTree(v interface{}) error {
+	if old, ok := c.DataSubTree.(map[string]interface{}); ok {
+		if m, ok := v.(map[string]interface{}); ok {
+			for k := range old {
+				delete(old, k)
+			}
+			for k, x := range m {
+				old[k] = x
+			}
+			return nil
+		}
+	}
+	// the copies made by Err(), Ok() etc. have no path, and share the value with their parents,
+	// so it goes up to the first one with a path, or to the root
+	for p := c; ; p = p.parent {
+		p.DataSubTree = v
+		if p.parent == nil {
+			return nil
+		}
+		if len(p.relativePathFromParent) > 0 {
+			return set(p.parent.DataSubTree, p.relativePathFromParent, v)
+		}
+	}
+}
+
+// Applies the patch to the subtree at the path; used by the updater of the Source.
+func (c *Config) applyPatchAt(path []string, patch treePatch) error {
+	sub, err := goByExactPath(c.DataSubTree, path)
+	if err != nil {
+		return err
+	}
+	v, err := patch.apply(sub)
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		c.DataSubTree = v
+		return nil
+	}
+	return set(c.DataSubTree, path, v)
+}
+
+type jsonPatch []*jsonPatchOp
+
+type jsonPatchOp struct {
+	op      string
+	pathStr string
+	path    []string
+	from    []string
+	value   interface{}
+}
+
+func parseJSONPatch(b []byte) (jsonPatch, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("Invalid JSON patch: %w", err)
+	}
+	patch := make(jsonPatch, 0, len(raw))
+	for i, r := range raw {
+		op := &jsonPatchOp{}
+		fail := func(err error) (jsonPatch, error) {
+			return nil, &PatchError{Index: i, Op: op.op, Path: op.pathStr, Err: err}
+		}
+		str := func(name string) (string, error) {
+			var s string
+			m, ok := r[name]
+			if !ok {
+				return "", fmt.Errorf("no %q", name)
+			}
+			if err := json.Unmarshal(m, &s); err != nil {
+				return "", fmt.Errorf("invalid %q: %v", name, err)
+			}
+			return s, nil
+		}
+		var err error
+		if op.op, err = str("op"); err != nil {
+			return fail(err)
+		}
+		if op.pathStr, err = str("path"); err != nil {
+			return fail(err)
+		}
+		if op.path, err = parseJSONPointer(op.pathStr); err != nil {
+			return fail(&InvalidPathError{Path: []string{op.pathStr}, Reason: err.Error()})
+		}
+		switch op.op {
+		case "add", "replace", "test":
+			m, ok := r["value"]
+			if !ok {
+				return fail(fmt.Errorf("no \"value\""))
+			}
+			if err = json.Unmarshal(m, &op.value); err != nil {
+				return fail(fmt.Errorf("invalid \"value\": %v", err))
+			}
+			if op.value, err = normalizeValue(op.value); err != nil {
+				return fail(err)
+			}
+		case "move", "copy":
+			from, err := str("from")
+			if err != nil {
+				return fail(err)
+			}
+			if op.from, err = parseJSONPointer(from); err != nil {
+				return fail(&InvalidPathError{Path: []string{from}, Reason: err.Error()})
+			}
+		case "remove":
+		default:
+			return fail(fmt.Errorf("unknown operation %q", op.op))
+		}
+		patch = append(patch, op)
+	}
+	return patch, nil
+}
+
+func (patch jsonPatch) apply(doc interface{}) (interface{}, error) {
+	doc = deepcopy.Copy(doc)
+	for i, op := range patch {
+		var err error
+		if doc, err = op.apply(doc); err != nil {
+			return nil, &PatchError{Index: i, Op: op.op, Path: op.pathStr, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+func (op *jsonPatchOp) apply(doc interface{}) (interface{}, error) {
+	switch op.op {
+	case "add":
+		return patchAdd(doc, op.path, deepcopy.Copy(op.value))
+	case "remove":
+		doc, _, err := patchRemove(doc, op.path)
+		return doc, err
+	case "replace":
+		if _, err := goByPointer(doc, op.path); err != nil {
+			return nil, err
+		}
+		if len(op.path) == 0 {
+			return deepcopy.Copy(op.value), nil
+		}
+		return patchPut(doc, op.path, deepcopy.Copy(op.value))
+	case "move":
+		if pathKey(op.from) == pathKey(op.path) {
+			_, err := goByPointer(doc, op.from)
+			return doc, err
+		}
+		if isPathPrefix(op.from, op.path) {
+			return nil, &InvalidPathError{Path: op.path, Reason: "can't move a value into itself"}
+		}
+		doc, v, err := patchRemove(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, op.path, v)
+	case "copy":
+		v, err := goByPointer(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(doc, op.path, deepcopy.Copy(v))
+	case "test":
+		v, err := goByPointer(doc, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !schemaEqual(v, op.value) {
+			return nil, ErrPatchTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.op)
+}
+
+// Adds the v to the map, or inserts it into the list, at the path; "-" appends to the list.
+func patchAdd(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+	parent, err := goByPointer(doc, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		parent[last] = v
+		return doc, nil
+	case []interface{}:
+		i := len(parent)
+		if last != "-" && last != strconv.Itoa(len(parent)) {
+			if i, err = patchIndex(path, len(parent)); err != nil {
+				return nil, err
+			}
+		}
+		l := make([]interface{}, 0, len(parent)+1)
+		l = append(append(append(l, parent[:i]...), v), parent[i:]...)
+		return patchPut(doc, parentPath, l)
+	}
+	return nil, &InvalidPathError{Path: path, Reason: fmt.Sprintf("can't add to %T", parent)}
+}
+
+// Removes the value at the path, and returns it.
+func patchRemove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, &InvalidPathError{Path: path, Reason: "can't remove the root"}
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+	parent, err := goByPointer(doc, parentPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		v, ok := parent[last]
+		if !ok {
+			return nil, nil, &NotFoundError{Path: path}
+		}
+		delete(parent, last)
+		return doc, v, nil
+	case []interface{}:
+		i, err := patchIndex(path, len(parent))
+		if err != nil {
+			return nil, nil, err
+		}
+		v := parent[i]
+		l := append(append(make([]interface{}, 0, len(parent)-1), parent[:i]...), parent[i+1:]...)
+		doc, err = patchPut(doc, parentPath, l)
+		return doc, v, err
+	}
+	return nil, nil, &InvalidPathError{Path: path, Reason: fmt.Sprintf("can't remove from %T", parent)}
+}
+
+// Puts the v at the existing path, and returns the doc, which is the v itself for the root.
+func patchPut(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+	parent, err := goByPointer(doc, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		parent[last] = v
+	case []interface{}:
+		i, err := patchIndex(path, len(parent))
+		if err != nil {
+			return nil, err
+		}
+		parent[i] = v
+	default:
+		return nil, &InvalidPathError{Path: path, Reason: fmt.Sprintf("can't set in %T", parent)}
+	}
+	return doc, nil
+}
+
+// Parses the last part of the path as a list index, below the n; RFC 6901 allows no leading zeros.
+func patchIndex(path []string, n int) (int, error) {
+	s := path[len(path)-1]
+	i, err := strconv.Atoi(s)
+	if err != nil || !isListIndex(s) {
+		return 0, &InvalidPathError{Path: path, Reason: "invalid list index"}
+	}
+	if i >= n {
+		return 0, &IndexOutOfRangeError{Path: path, Len: n}
+	}
+	return i, nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Traverses the config down the RFC 6901 JSON Pointer, relative to the current location, e.g.
+// "/servers/0/host". Unlike with DotP() and SlashP(), any key can be addressed, as the "~" and
+// "/" in the keys are escaped as "~0" and "~1": "/hosts/example.com~1api". The "" is the
+// current location itself.
+func (c *Config) Pointer(pointer string) *Config {
+	c2 := c.ChildCopy()
+	parts, err := parseJSONPointer(pointer)
+	if err != nil {
+		c2.DataSubTree = nil
+		c2.handleError(c.located(&InvalidPathError{Reason: err.Error()}))
+		return c2
+	}
+	c2.DataSubTree, err = goByPointer(c2.DataSubTree, parts)
+	if err != nil {
+		c2.handleError(c.located(err))
+	}
+	c2.relativePathFromParent = parts
+	c2.recordPath(err == nil)
+	return c2
+}
+
+// Returns the absolute location as the RFC 6901 JSON Pointer, which is a lossless textual
+// form of the path, usable with Pointer() on the root config.
+func (c *Config) PointerString() string {
+	return formatJSONPointer(c.GetCurrentLocationPlusPath())
+}
+
+// goByPointer is the goByExactPath, but with the list indices as the RFC 6901 defines them,
+// so "-1", "+1" and "01" are invalid.
+func goByPointer(c interface{}, parts []string) (interface{}, error) {
+	for pos, part := range parts {
+		if _, ok := c.([]interface{}); ok && !isListIndex(part) {
+			return nil, &InvalidPathError{Path: parts[:pos+1], Reason: "invalid list index"}
+		}
+		next, err := goByExactPath(c, []string{part})
+		if err != nil {
+			return nil, withLocation(err, parts[:pos])
+		}
+		c = next
+	}
+	return c, nil
+}
+
+// parseJSONPointer splits the RFC 6901 pointer into the unescaped parts.
+func parseJSONPointer(p string) ([]string, error) {
+	if p == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("JSON pointer must start with '/': %q", p)
+	}
+	parts := strings.Split(p[1:], "/")
+	for i, part := range parts {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(part, "~0", ""), "~1", ""), "~") {
+			return nil, fmt.Errorf("invalid escape in the JSON pointer %q", p)
+		}
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func formatJSONPointer(path []string) string {
+	var b strings.Builder
+	for _, part := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(part, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
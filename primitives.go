@@ -6,6 +6,7 @@ import (
 )
 
 func (c *Config) Bool(defaultValueFunc ...func() bool) bool {
+	defer c.recordAccess("bool", c.ExpressionStatus)
 	n := c.DataSubTree
 	switch n := n.(type) {
 	case bool:
@@ -13,7 +14,7 @@ func (c *Config) Bool(defaultValueFunc ...func() bool) bool {
 	case string:
 		b, err := strconv.ParseBool(n)
 		if err != nil {
-			c.handleError(err)
+			c.handleError(c.located(conversionError("bool", n, err)))
 			if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 				if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 					panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -26,7 +27,7 @@ func (c *Config) Bool(defaultValueFunc ...func() bool) bool {
 		}
 		return b
 	default:
-		c.handleError(typeMismatchError("bool or string", n))
+		c.handleError(c.located(typeMismatchError("bool or string", n)))
 		if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 			if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 				panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -40,6 +41,7 @@ func (c *Config) Bool(defaultValueFunc ...func() bool) bool {
 }
 
 func (c *Config) Float64(defaultValueFunc ...func() float64) float64 {
+	defer c.recordAccess("float64", c.ExpressionStatus)
 	n := c.DataSubTree
 	switch n := n.(type) {
 	case float64:
@@ -49,7 +51,7 @@ func (c *Config) Float64(defaultValueFunc ...func() float64) float64 {
 	case string:
 		b, err := strconv.ParseFloat(n, 64)
 		if err != nil {
-			c.handleError(err)
+			c.handleError(c.located(conversionError("float64", n, err)))
 			if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 				if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 					panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -62,7 +64,7 @@ func (c *Config) Float64(defaultValueFunc ...func() float64) float64 {
 		}
 		return b
 	default:
-		c.handleError(typeMismatchError("float64, int or string", n))
+		c.handleError(c.located(typeMismatchError("float64, int or string", n)))
 		if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 			if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 				panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -76,15 +78,15 @@ func (c *Config) Float64(defaultValueFunc ...func() float64) float64 {
 }
 
 func (c *Config) Int(defaultValueFunc ...func() int) int {
+	defer c.recordAccess("int", c.ExpressionStatus)
 	n := c.DataSubTree
-	var err error
 	switch n := n.(type) {
 	case float64:
 		// encoding/json unmarshals numbers into floats
 		if i := int(n); float64(i) == n {
 			return i
 		}
-		c.handleError(fmt.Errorf("Value can't be converted to int: %v", n))
+		c.handleError(c.located(conversionError("int", n, fmt.Errorf("Value can't be converted to int: %v", n))))
 		if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 			if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 				panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -97,10 +99,11 @@ func (c *Config) Int(defaultValueFunc ...func() int) int {
 	case int:
 		return n
 	case string:
-		if v, err := strconv.ParseInt(n, 10, 0); err == nil {
+		v, err := strconv.ParseInt(n, 10, 0)
+		if err == nil {
 			return int(v)
 		}
-		c.handleError(err)
+		c.handleError(c.located(conversionError("int", n, err)))
 		if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 			if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 				panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -111,7 +114,7 @@ func (c *Config) Int(defaultValueFunc ...func() int) int {
 			return 0
 		}
 	default:
-		c.handleError(typeMismatchError("float64, int or string", n))
+		c.handleError(c.located(typeMismatchError("float64, int or string", n)))
 		if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 			if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 				panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -125,6 +128,7 @@ func (c *Config) Int(defaultValueFunc ...func() int) int {
 }
 
 func (c *Config) String(defaultValueFunc ...func() string) string {
+	defer c.recordAccess("string", c.ExpressionStatus)
 	n := c.DataSubTree
 	switch n := n.(type) {
 	case bool, float64, int:
@@ -132,7 +136,7 @@ func (c *Config) String(defaultValueFunc ...func() string) string {
 	case string:
 		return n
 	default:
-		c.handleError(typeMismatchError("bool, float64, int or string", n))
+		c.handleError(c.located(typeMismatchError("bool, float64, int or string", n)))
 		if len(defaultValueFunc) > 0 && !c.isExpressionOk() {
 			if c.ExpressionStatus == ExpressionStatus_2_DefaultCallbackAlreadyUsedOnce {
 				panic(ErrMsg_MultipleCallbackWithoutPriorErrOk)
@@ -2,33 +2,72 @@ package config
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
-	"strings"
 )
 
 func getAllPaths(source interface{}, base ...string) [][]string {
 	paths := [][]string{}
+	walkTree(source, base, func(path []string, v interface{}) error {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+// walkTree calls the f for the value and all its descendants, in pre-order, with the map keys
+// sorted. The path given to the f is its own copy. If the f returns SkipSubtree, the children
+// of the value are skipped; other errors stop the walk, and are returned.
+func walkTree(v interface{}, path []string, f func(path []string, v interface{}) error) error {
+	if err := f(append(make([]string, 0, len(path)), path...), v); err != nil {
+		if err == SkipSubtree {
+			return nil
+		}
+		return err
+	}
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := walkTree(vv[k], appendPath(path, k), f); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, x := range vv {
+			if err := walkTree(x, appendPath(path, strconv.Itoa(i)), f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getListPaths returns the paths of all the lists in the tree, including nested ones.
+func getListPaths(source interface{}, base ...string) [][]string {
+	paths := [][]string{}
 
-	// Copy "base" so that underlying slice array is not
-	// modified in recursive calls
 	nextBase := make([]string, len(base))
 	copy(nextBase, base)
 
 	switch c := source.(type) {
 	case map[string]interface{}:
 		for k, v := range c {
-			keys := getAllPaths(v, append(nextBase, k)...)
-			paths = append(paths, keys...)
+			paths = append(paths, getListPaths(v, append(nextBase, k)...)...)
 		}
 	case []interface{}:
+		paths = append(paths, nextBase)
 		for i, v := range c {
-			k := strconv.Itoa(i)
-			keys := getAllPaths(v, append(nextBase, k)...)
-			paths = append(paths, keys...)
+			paths = append(paths, getListPaths(v, append(nextBase, strconv.Itoa(i))...)...)
 		}
-	default:
-		paths = append(paths, nextBase)
-		return paths
 	}
 	return paths
 }
@@ -41,37 +80,38 @@ func goByPath(c interface{}, pathParts []string) (interface{}, error) {
 			if k == 0 {
 				pathParts = pathParts[1:]
 			} else {
-				return nil, fmt.Errorf("Invalid path %q", strings.Join(pathParts, "."))
+				return nil, &InvalidPathError{Path: pathParts, Reason: "empty path part"}
 			}
 		}
 	}
-	// Get the value.
+	return goByExactPath(c, pathParts)
+}
+
+// goByExactPath is the goByPath without the normalization, so the empty keys are keys too.
+func goByExactPath(c interface{}, pathParts []string) (interface{}, error) {
 	for pos, part := range pathParts {
 		switch cv := c.(type) {
 		case []interface{}:
 			if i, error := strconv.ParseInt(part, 10, 0); error == nil {
-				if int(i) < len(cv) {
+				if i < 0 {
+					return nil, &InvalidPathError{Path: pathParts[:pos+1], Reason: "negative list index"}
+				} else if int(i) < len(cv) {
 					c = cv[i]
 				} else {
-					return nil, fmt.Errorf(
-						"Index out of range at %q: list has only %v items",
-						strings.Join(pathParts[:pos+1], "."), len(cv))
+					return nil, &IndexOutOfRangeError{Path: pathParts[:pos+1], Len: len(cv)}
 				}
 			} else {
-				return nil, fmt.Errorf("Invalid list index at %q",
-					strings.Join(pathParts[:pos+1], "."))
+				return nil, &InvalidPathError{Path: pathParts[:pos+1], Reason: "invalid list index"}
 			}
 		case map[string]interface{}:
 			if value, ok := cv[part]; ok {
 				c = value
 			} else {
-				return nil, fmt.Errorf("Nonexistent map key at %q",
-					strings.Join(pathParts[:pos+1], "."))
+				return nil, &NotFoundError{Path: pathParts[:pos+1]}
 			}
 		default:
-			return nil, fmt.Errorf(
-				"Invalid type at %q: expected []interface{} or map[string]interface{}; got %T",
-				strings.Join(pathParts[:pos+1], "."), c)
+			return nil, &InvalidPathError{Path: pathParts[:pos+1],
+				Reason: fmt.Sprintf("expected []interface{} or map[string]interface{}; got %T", c)}
 		}
 	}
 
@@ -86,7 +126,7 @@ func set(c interface{}, pathParts []string, value interface{}) error {
 			if k == 0 {
 				pathParts = pathParts[1:]
 			} else {
-				return fmt.Errorf("Invalid path %q", pathParts)
+				return &InvalidPathError{Path: pathParts, Reason: "empty path part"}
 			}
 		}
 	}
@@ -100,8 +140,14 @@ func set(c interface{}, pathParts []string, value interface{}) error {
 			if i64, error := strconv.ParseInt(pathPart_str, 10, 0); error == nil {
 				// it's a number, okay; we've also parsed it
 				i := int(i64)
+				if i < 0 {
+					return &InvalidPathError{Path: pathParts[:pathPart_i+1], Reason: "negative list index"}
+				}
 
 				// don't enlarge the array here, as it won't be saved in its parent then
+				if i >= len(now_typed) {
+					return &IndexOutOfRangeError{Path: pathParts[:pathPart_i+1], Len: len(now_typed)}
+				}
 
 				if pathPart_i+1 == len(pathParts) {
 					// this is the last pp, which is indeed a number, we're in an array;
@@ -137,6 +183,9 @@ func set(c interface{}, pathParts []string, value interface{}) error {
 						// is next path part a string (map key) or number (array index)?
 						if i, err := strconv.ParseInt(pathParts[pathPart_i+1], 10, 0); err == nil {
 							// next pp was a number, so create a nested array
+							if !isListIndex(pathParts[pathPart_i+1]) {
+								return &InvalidPathError{Path: pathParts[:pathPart_i+2], Reason: "invalid list index"}
+							}
 							val = make([]interface{}, int(i)+1, int(i)+1)
 						} else {
 							// next pp was a string, to create a nested map
@@ -148,8 +197,7 @@ func set(c interface{}, pathParts []string, value interface{}) error {
 				}
 
 			} else {
-				return fmt.Errorf("Invalid list index at %q",
-					strings.Join(pathParts[:pathPart_i+1], "."))
+				return &InvalidPathError{Path: pathParts[:pathPart_i+1], Reason: "invalid list index"}
 			}
 
 		case map[string]interface{}:
@@ -186,6 +234,9 @@ func set(c interface{}, pathParts []string, value interface{}) error {
 					next_pp := pathParts[pathPart_i+1]
 					if i, err := strconv.ParseInt(next_pp, 10, 0); err == nil {
 						// next pp was a number, so create a nested array
+						if !isListIndex(next_pp) {
+							return &InvalidPathError{Path: pathParts[:pathPart_i+2], Reason: "invalid list index"}
+						}
 						val = make([]interface{}, int(i)+1, int(i)+1)
 					} else {
 						// next pp was a string, to create a nested map
@@ -201,17 +252,72 @@ func set(c interface{}, pathParts []string, value interface{}) error {
 			}
 
 		default:
-			return fmt.Errorf(
-				"Invalid type at %q: expected []interface{} or map[string]interface{}; got %T",
-				strings.Join(pathParts[:pathPart_i+1], "."), now_typed)
+			return &InvalidPathError{Path: pathParts[:pathPart_i+1],
+				Reason: fmt.Sprintf("expected []interface{} or map[string]interface{}; got %T", now_typed)}
 		}
 	}
 	return nil
 }
 
+// deleteByPath removes the map key or the list item at the path; a list item can't be removed
+// from the root list, as the list is replaced by a shorter one in its parent.
+func deleteByPath(c interface{}, pathParts []string) error {
+	if len(pathParts) == 0 {
+		return &InvalidPathError{Path: pathParts, Reason: "can't delete the root"}
+	}
+	parentPath, last := pathParts[:len(pathParts)-1], pathParts[len(pathParts)-1]
+	parent, err := goByPath(c, parentPath)
+	if err != nil {
+		return err
+	}
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := parent[last]; !ok {
+			return &NotFoundError{Path: pathParts}
+		}
+		delete(parent, last)
+	case []interface{}:
+		i, err := strconv.Atoi(last)
+		if err != nil {
+			return &InvalidPathError{Path: pathParts, Reason: "invalid list index"}
+		}
+		if i < 0 || i >= len(parent) {
+			return &IndexOutOfRangeError{Path: pathParts, Len: len(parent)}
+		}
+		if len(parentPath) == 0 {
+			return &InvalidPathError{Path: pathParts, Reason: "can't delete an item of the root list"}
+		}
+		l := append(append(make([]interface{}, 0, len(parent)-1), parent[:i]...), parent[i+1:]...)
+		return set(c, parentPath, l)
+	default:
+		return &InvalidPathError{Path: pathParts,
+			Reason: fmt.Sprintf("expected []interface{} or map[string]interface{}; got %T", parent)}
+	}
+	return nil
+}
+
+// isListIndex tells whether the s is a list index as RFC 6901 defines it: 0, or a number
+// without a sign and leading zeros.
+func isListIndex(s string) bool {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // typeMismatchError returns an error for an expected type.
 func typeMismatchError(expected string, got interface{}) error {
-	return fmt.Errorf("Type mismatch: expected %s; got %T", expected, got)
+	return &TypeMismatchError{Expected: expected, Got: fmt.Sprintf("%T", got)}
+}
+
+// conversionError returns an error for a value, which can't be converted to the expected type.
+func conversionError(expected string, got interface{}, err error) error {
+	return &TypeMismatchError{Expected: expected, Got: fmt.Sprintf("%T", got), Err: err}
 }
 
 // normalizeValue normalizes a unmarshalled value. This is needed because
@@ -257,3 +363,43 @@ func normalizeValue(value interface{}) (interface{}, error) {
 	}
 	return nil, fmt.Errorf("Unsupported type: %T", value)
 }
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// suggest returns the candidate closest to the name, if it's close enough, or "".
+func suggest(name string, candidates []string) string {
+	best, bestDist := "", len(name)/3+2
+	for _, c := range candidates {
+		if d := levenshtein(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
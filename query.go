@@ -0,0 +1,252 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Query() returns all the values matching the path pattern, in order, with their locations
+// set, so that Set() and GetCurrentLocationPlusPath() work on them as after P(). The parts
+// of the pattern are:
+//
+//	key, 0, [key]        the exact map key or list index, same as with P()
+//	*                    every map value or list item
+//	**                   any number of levels, including none
+//	[1:3], [:2], [-1:]   the list items of the slice, as in Go, negative ones count from the end
+//	[?enabled==true]     the map values or list items, which have the field with the value;
+//	                     the operators are ==, !=, <, <=, >, >=, and [?enabled] tests for existence
+//
+// E.g. c.Query("servers", "*", "host"), or c.Query("clients", "**", "timeout"). Nothing
+// matching is not an error, but an invalid pattern is.
+func (c *Config) Query(pattern ...string) []*Config {
+	q := &querier{seen: map[string]bool{}}
+	if err := q.query(c.DataSubTree, pattern, nil); err != nil {
+		c.handleError(c.located(err))
+		return nil
+	}
+	result := make([]*Config, 0, len(q.matches))
+	for _, m := range q.matches {
+		c2 := c.ChildCopy()
+		c2.DataSubTree = m.v
+		c2.relativePathFromParent = m.path
+		c2.recordPath(true)
+		result = append(result, c2)
+	}
+	return result
+}
+
+type queryMatch struct {
+	path []string
+	v    interface{}
+}
+
+type querier struct {
+	matches []queryMatch
+	seen    map[string]bool
+}
+
+func (q *querier) query(v interface{}, pattern []string, base []string) error {
+	if len(pattern) == 0 {
+		if !q.seen[pathKey(base)] {
+			q.seen[pathKey(base)] = true
+			q.matches = append(q.matches, queryMatch{path: base, v: v})
+		}
+		return nil
+	}
+	part, rest := pattern[0], pattern[1:]
+	switch {
+	case part == "**":
+		if err := q.query(v, rest, base); err != nil {
+			return err
+		}
+		for _, ch := range queryChildren(v) {
+			if err := q.query(ch.v, pattern, appendPath(base, ch.path[0])); err != nil {
+				return err
+			}
+		}
+	case part == "*":
+		for _, ch := range queryChildren(v) {
+			if err := q.query(ch.v, rest, appendPath(base, ch.path[0])); err != nil {
+				return err
+			}
+		}
+	case strings.HasPrefix(part, "[?") && strings.HasSuffix(part, "]"):
+		f, err := parseQueryFilter(part[2 : len(part)-1])
+		if err != nil {
+			return &InvalidPathError{Path: appendPath(base, part), Reason: err.Error()}
+		}
+		for _, ch := range queryChildren(v) {
+			if f.match(ch.v) {
+				if err := q.query(ch.v, rest, appendPath(base, ch.path[0])); err != nil {
+					return err
+				}
+			}
+		}
+	case strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") && strings.Contains(part, ":"):
+		l, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		from, to, err := parseQuerySlice(part[1:len(part)-1], len(l))
+		if err != nil {
+			return &InvalidPathError{Path: appendPath(base, part), Reason: err.Error()}
+		}
+		for i := from; i < to; i++ {
+			if err := q.query(l[i], rest, appendPath(base, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	default:
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			part = part[1 : len(part)-1]
+		}
+		child, err := goByPath(v, []string{part})
+		if err != nil {
+			// not matching
+			return nil
+		}
+		return q.query(child, rest, appendPath(base, part))
+	}
+	return nil
+}
+
+// Returns the map values, sorted by key, or the list items, with their single-part paths.
+func queryChildren(v interface{}) []queryMatch {
+	var children []queryMatch
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			children = append(children, queryMatch{path: []string{k}, v: vv[k]})
+		}
+	case []interface{}:
+		for i, x := range vv {
+			children = append(children, queryMatch{path: []string{strconv.Itoa(i)}, v: x})
+		}
+	}
+	return children
+}
+
+// Parses the "from:to" of the list of the length n, into the bounds within the list.
+func parseQuerySlice(s string, n int) (from, to int, err error) {
+	fromStr, toStr, _ := strings.Cut(s, ":")
+	bound := func(s string, def int) (int, error) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return def, nil
+		}
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid slice bound %q", s)
+		}
+		if i < 0 {
+			i += n
+		}
+		if i < 0 {
+			i = 0
+		}
+		if i > n {
+			i = n
+		}
+		return i, nil
+	}
+	if from, err = bound(fromStr, 0); err != nil {
+		return
+	}
+	if to, err = bound(toStr, n); err != nil {
+		return
+	}
+	if to < from {
+		to = from
+	}
+	return
+}
+
+// queryFilter is the parsed "field op value", or just "field" to test for existence.
+type queryFilter struct {
+	field []string
+	op    string
+	value interface{}
+}
+
+var queryFilterOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func parseQueryFilter(s string) (*queryFilter, error) {
+	for _, op := range queryFilterOps {
+		if i := strings.Index(s, op); i >= 0 {
+			f := &queryFilter{field: SplitPathToParts(strings.TrimSpace(s[:i])), op: op}
+			if err := yaml.Unmarshal([]byte(strings.TrimSpace(s[i+len(op):])), &f.value); err != nil {
+				return nil, fmt.Errorf("invalid filter value in %q: %v", s, err)
+			}
+			if len(f.field) == 0 {
+				return nil, fmt.Errorf("no field in the filter %q", s)
+			}
+			return f, nil
+		}
+	}
+	if strings.TrimSpace(s) == "" {
+		return nil, fmt.Errorf("empty filter")
+	}
+	return &queryFilter{field: SplitPathToParts(strings.TrimSpace(s))}, nil
+}
+
+func (f *queryFilter) match(v interface{}) bool {
+	x, err := goByPath(v, f.field)
+	if err != nil {
+		return false
+	}
+	return compareValues(x, f.op, f.value)
+}
+
+// compareValues compares the values as JSON would; the ordering is defined for numbers and strings.
+// An empty op is the test for existence, and is always true.
+func compareValues(a interface{}, op string, b interface{}) bool {
+	switch op {
+	case "":
+		return true
+	case "==":
+		return schemaEqual(a, b)
+	case "!=":
+		return !schemaEqual(a, b)
+	}
+	var cmp int
+	if na, ok := schemaNumber(a); ok {
+		nb, ok := schemaNumber(b)
+		if !ok {
+			return false
+		}
+		switch {
+		case na < nb:
+			cmp = -1
+		case na > nb:
+			cmp = 1
+		}
+	} else if sa, ok := a.(string); ok {
+		sb, ok := b.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(sa, sb)
+	} else {
+		return false
+	}
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaViolation is a single failed check of ValidateSchema(). The Path is relative to the
+// validated config, as used with P(); the Origin is the file the config was loaded from, if known.
+type SchemaViolation struct {
+	Path    []string
+	Keyword string
+	Message string
+	Origin  string
+}
+
+func (v SchemaViolation) String() string {
+	s := fmt.Sprintf("%q: %v", strings.Join(v.Path, "."), v.Message)
+	if v.Origin != "" {
+		s += fmt.Sprintf(" (in %v)", v.Origin)
+	}
+	return s
+}
+
+// SchemaError is returned by ValidateSchema(), with all the violations found.
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	ss := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		ss = append(ss, v.String())
+	}
+	return fmt.Sprintf("%v schema violation(s): %v", len(e.Violations), strings.Join(ss, "; "))
+}
+
+// ValidateSchema() validates the config at the current location against the JSON Schema,
+// which can be loaded as any other config. A subset of the draft 2020-12 is supported:
+// type, enum, const, minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength,
+// maxLength, pattern, properties, required, additionalProperties, items, minItems, maxItems,
+// and $ref to "#" or "#/json/pointer" within the same schema, e.g. "#/$defs/server".
+// Returns nil if the config is valid, or the *SchemaError with all the violations.
+func (c *Config) ValidateSchema(schema *Config) error {
+	sv := &schemaValidator{
+		root:     schemaRoot(schema),
+		origin:   c.origin,
+		patterns: map[string]*regexp.Regexp{},
+	}
+	sv.validate(nil, c.DataSubTree, schema.DataSubTree, 0)
+	if len(sv.violations) > 0 {
+		return &SchemaError{Violations: sv.violations}
+	}
+	return nil
+}
+
+type schemaValidator struct {
+	root       interface{}
+	origin     string
+	patterns   map[string]*regexp.Regexp
+	violations []SchemaViolation
+}
+
+const schemaMaxRefDepth = 100
+
+func (sv *schemaValidator) fail(path []string, keyword string, format string, args ...interface{}) {
+	sv.violations = append(sv.violations, SchemaViolation{
+		Path:    path,
+		Keyword: keyword,
+		Message: fmt.Sprintf(format, args...),
+		Origin:  sv.origin,
+	})
+}
+
+func (sv *schemaValidator) validate(path []string, v interface{}, schema interface{}, refDepth int) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			sv.fail(path, "false", "no value is allowed here")
+		}
+		return
+	case map[string]interface{}:
+		sv.validateObject(path, v, s, refDepth)
+	default:
+		sv.fail(path, "", "invalid schema: expected an object or a bool, got %T", schema)
+	}
+}
+
+func (sv *schemaValidator) validateObject(path []string, v interface{}, s map[string]interface{}, refDepth int) {
+	if ref, ok := s["$ref"].(string); ok {
+		if refDepth >= schemaMaxRefDepth {
+			sv.fail(path, "$ref", "too deep $ref %q", ref)
+			return
+		}
+		target, err := sv.resolveRef(ref)
+		if err != nil {
+			sv.fail(path, "$ref", "%v", err)
+			return
+		}
+		sv.validate(path, v, target, refDepth+1)
+	}
+
+	if t, ok := s["type"]; ok {
+		types := []string{}
+		switch t := t.(type) {
+		case string:
+			types = append(types, t)
+		case []interface{}:
+			for _, x := range t {
+				types = append(types, fmt.Sprint(x))
+			}
+		}
+		matched := false
+		for _, t := range types {
+			if schemaTypeMatches(t, v) {
+				matched = true
+			}
+		}
+		if !matched {
+			sv.fail(path, "type", "expected %v, got %v", strings.Join(types, " or "), schemaTypeOf(v))
+			// the rest of the checks make no sense then
+			return
+		}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, x := range enum {
+			if schemaEqual(x, v) {
+				found = true
+			}
+		}
+		if !found {
+			sv.fail(path, "enum", "%v is not one of %v", formatFlagDefault(v), formatFlagDefault(enum))
+		}
+	}
+	if cnst, ok := s["const"]; ok && !schemaEqual(cnst, v) {
+		sv.fail(path, "const", "expected %v, got %v", formatFlagDefault(cnst), formatFlagDefault(v))
+	}
+
+	if n, ok := schemaNumber(v); ok {
+		if m, ok := schemaNumber(s["minimum"]); ok && n < m {
+			sv.fail(path, "minimum", "%v is less than %v", n, m)
+		}
+		if m, ok := schemaNumber(s["maximum"]); ok && n > m {
+			sv.fail(path, "maximum", "%v is greater than %v", n, m)
+		}
+		if m, ok := schemaNumber(s["exclusiveMinimum"]); ok && n <= m {
+			sv.fail(path, "exclusiveMinimum", "%v is not greater than %v", n, m)
+		}
+		if m, ok := schemaNumber(s["exclusiveMaximum"]); ok && n >= m {
+			sv.fail(path, "exclusiveMaximum", "%v is not less than %v", n, m)
+		}
+	}
+
+	if str, ok := v.(string); ok {
+		l := float64(utf8.RuneCountInString(str))
+		if m, ok := schemaNumber(s["minLength"]); ok && l < m {
+			sv.fail(path, "minLength", "length %v is less than %v", l, m)
+		}
+		if m, ok := schemaNumber(s["maxLength"]); ok && l > m {
+			sv.fail(path, "maxLength", "length %v is greater than %v", l, m)
+		}
+		if p, ok := s["pattern"].(string); ok {
+			re, err := sv.pattern(p)
+			if err != nil {
+				sv.fail(path, "pattern", "invalid pattern %q: %v", p, err)
+			} else if !re.MatchString(str) {
+				sv.fail(path, "pattern", "%q doesn't match the pattern %q", str, p)
+			}
+		}
+	}
+
+	if obj, ok := v.(map[string]interface{}); ok {
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, r := range required {
+				if _, exists := obj[fmt.Sprint(r)]; !exists {
+					sv.fail(appendPath(path, fmt.Sprint(r)), "required", "required key is missing")
+				}
+			}
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		additional, hasAdditional := s["additionalProperties"]
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := properties[k]; ok {
+				sv.validate(appendPath(path, k), obj[k], ps, refDepth)
+			} else if hasAdditional {
+				if b, ok := additional.(bool); ok && !b {
+					sv.fail(appendPath(path, k), "additionalProperties", "key is not allowed")
+				} else {
+					sv.validate(appendPath(path, k), obj[k], additional, refDepth)
+				}
+			}
+		}
+	}
+
+	if list, ok := v.([]interface{}); ok {
+		l := float64(len(list))
+		if m, ok := schemaNumber(s["minItems"]); ok && l < m {
+			sv.fail(path, "minItems", "%v items is less than %v", l, m)
+		}
+		if m, ok := schemaNumber(s["maxItems"]); ok && l > m {
+			sv.fail(path, "maxItems", "%v items is more than %v", l, m)
+		}
+		if items, ok := s["items"]; ok {
+			for i, x := range list {
+				sv.validate(appendPath(path, strconv.Itoa(i)), x, items, refDepth)
+			}
+		}
+	}
+}
+
+// Returns the whole schema, the refs are relative to, even if its subtree is used.
+func schemaRoot(schema *Config) interface{} {
+	for schema.parent != nil {
+		schema = schema.parent
+	}
+	return schema.DataSubTree
+}
+
+func (sv *schemaValidator) resolveRef(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q: only refs within the schema are supported", ref)
+	}
+	parts, err := parseJSONPointer(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q: %v", ref, err)
+	}
+	target, err := goByExactPath(sv.root, parts)
+	if err != nil {
+		return nil, fmt.Errorf("unresolvable $ref %q: %v", ref, err)
+	}
+	return target, nil
+}
+
+func (sv *schemaValidator) pattern(p string) (*regexp.Regexp, error) {
+	if re, ok := sv.patterns[p]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+	sv.patterns[p] = re
+	return re, nil
+}
+
+func schemaTypeOf(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case int:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func schemaTypeMatches(t string, v interface{}) bool {
+	actual := schemaTypeOf(v)
+	return t == actual || (t == "number" && actual == "integer")
+}
+
+func schemaNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Compares the values as JSON would, so that 1 and 1.0 are equal.
+func schemaEqual(a, b interface{}) bool {
+	if na, ok := schemaNumber(a); ok {
+		nb, ok := schemaNumber(b)
+		return ok && na == nb
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, x := range av {
+			if y, ok := bv[k]; !ok || !schemaEqual(x, y) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !schemaEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
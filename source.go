@@ -19,12 +19,14 @@ type MsgCmd struct {
 	FullPath []string
 	V        any
 	Err      error
+	ChErr    chan error // if set, gets the Err, once the command is executed
 }
 
 type Command int
 
 const (
-	Command_Set Command = iota
+	Command_Set   Command = iota
+	Command_Patch         // the V is the parsed patch, see ApplyPatch() and ApplyMergePatch()
 )
 
 type MsgFlushSignal struct {
@@ -74,6 +76,7 @@ func (s *Source) theWriteBackUpdaterG() {
 		c2.parent = nil
 
 		xCloned := false
+		var replies []*MsgCmd
 		qLen := len(s.ChCmd)
 		for i := 0; i < qLen; i++ {
 			msg := <-s.ChCmd
@@ -84,12 +87,25 @@ func (s *Source) theWriteBackUpdaterG() {
 					xCloned = true
 				}
 				c2.NonThreadSafe_Set(msg.FullPath, msg.V)
+			case Command_Patch:
+				if !xCloned {
+					c2.DataSubTree = deepcopy.Copy(c2.DataSubTree)
+					xCloned = true
+				}
+				msg.Err = c2.applyPatchAt(msg.FullPath, msg.V.(treePatch))
+			}
+			if msg.ChErr != nil {
+				replies = append(replies, msg)
 			}
 		}
 
 		if xCloned {
 			s.Config = c2
 		}
+		// reply only after the c2 is published, so the callers see their changes
+		for _, msg := range replies {
+			msg.ChErr <- msg.Err
+		}
 
 		// PrintMemUsage()
 	}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_AccessRecorder_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  host: localhost
+  port: 8080
+  timeout: 5s
+db:
+  addr: db:5432
+  opts:
+    tls: true
+    pool: 5
+legacy:
+  flag: x
+`)).Load()
+
+	rec := &config.AccessRecorder{}
+	conf = conf.Record(rec)
+
+	var err error
+	_ = conf.DotP("server.host").String()
+	_ = conf.P("server", "port").Int()
+	_ = conf.P("server").P("timeout").Duration()
+	_ = conf.P("db", "opts").Map()
+	_ = conf.Err(&err).P("db", "retries").Int(func() int { return 3 })
+	err = nil
+	_ = conf.Err(&err).P("db", "addr").Bool(func() bool { return false })
+
+	expectedUsed := [][]string{
+		{"db", "opts"},
+		{"server", "host"},
+		{"server", "port"},
+		{"server", "timeout"},
+	}
+	if used := rec.Used(); !reflect.DeepEqual(used, expectedUsed) {
+		t.Fatalf("unexpected used %v", used)
+	}
+	expectedDefaulted := [][]string{
+		{"db", "addr"},
+		{"db", "retries"},
+	}
+	if defaulted := rec.Defaulted(); !reflect.DeepEqual(defaulted, expectedDefaulted) {
+		t.Fatalf("unexpected defaulted %v", defaulted)
+	}
+	expectedNeverRead := [][]string{
+		{"db", "addr"},
+		{"legacy", "flag"},
+	}
+	if neverRead := rec.NeverRead(conf); !reflect.DeepEqual(neverRead, expectedNeverRead) {
+		t.Fatalf("unexpected never read %v", neverRead)
+	}
+
+	for _, r := range rec.Records() {
+		t.Logf("%+v", r)
+		switch {
+		case reflect.DeepEqual(r.Path, []string{"server", "timeout"}):
+			if !reflect.DeepEqual(r.Types, []string{"time.Duration"}) || !r.Resolved || r.Count != 2 {
+				t.Fatalf("unexpected record %+v", r)
+			}
+		case reflect.DeepEqual(r.Path, []string{"db", "retries"}):
+			if r.Resolved || r.Read {
+				t.Fatalf("unexpected record %+v", r)
+			}
+		}
+	}
+}
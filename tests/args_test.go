@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_FromArgs_1(t *testing.T) {
+	var err error
+	ic := (&config.InitContext{}).FromArgs([]string{
+		"app",
+		"--config", "conf-test-files/layers/app.yaml",
+		"-f=conf-test-files/layers/conf.d/10-db.yaml",
+		"--set", "server.port=9090",
+		"--set=db.replicas[1].host=r1",
+		"--set-string", "version=1.10",
+		"--set-json", `db.opts={"tls": true, "ciphers": ["a", "b"]}`,
+		"--verbose",
+		"serve",
+		"--", "--set", "x=1",
+	})
+	conf := ic.Err(&err).Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := map[string]interface{}{
+		"server.host":        "example.com",
+		"server.port":        9090,
+		"db.addr":            "db.local",
+		"db.replicas.1.host": "r1",
+		"version":            "1.10",
+		"db.opts.tls":        true,
+		"db.opts.ciphers":    []interface{}{"a", "b"},
+	}
+	for path, v := range expected {
+		if got := conf.P(config.SplitPathToParts(path)...).DataSubTree; !reflect.DeepEqual(got, v) {
+			t.Fatalf("%v: expected %#v, got %#v", path, v, got)
+		}
+	}
+	if !reflect.DeepEqual(ic.RemainingArgs, []string{"--verbose", "serve", "--set", "x=1"}) {
+		t.Fatalf("unexpected remaining args %v", ic.RemainingArgs)
+	}
+}
+
+func Test_FromArgs_2_BadIndex(t *testing.T) {
+	for _, set := range []string{"servers[-1].x=1", "servers[+1].x=1", "servers[x]=1", "servers[0].ports[-2]=1",
+		"new[-3]=1", "new.x[+1]=1", "servers[0].new[01].x=1", "servers[1][-1]=1"} {
+		var err error
+		(&config.InitContext{}).
+			FromBytes([]byte(`servers: [{ports: [1]}]`)).
+			FromArgs([]string{"app", "--set", set}).
+			Err(&err).
+			Load()
+		if !errors.Is(err, config.ErrInvalidPath) {
+			t.Fatalf("%v: expected an invalid path error, got %v", set, err)
+		}
+	}
+}
+
+func Test_FromArgs_3_Paths(t *testing.T) {
+	cases := map[string][]string{
+		"a.b.c":             {"a", "b", "c"},
+		"a.b[2].c":          {"a", "b", "2", "c"},
+		"a[0][1]":           {"a", "0", "1"},
+		"root.[field.x.2]":  {"root", "field.x.2"},
+		"root.[f.4].field5": {"root", "f.4", "field5"},
+	}
+	for path, parts := range cases {
+		var err error
+		conf := (&config.InitContext{}).FromArgs([]string{"app", "--set", path + "=v"}).Err(&err).Load()
+		if err != nil {
+			t.Fatalf("%v: %v", path, err)
+		}
+		if got := conf.P(parts...).DataSubTree; got != "v" {
+			t.Fatalf("%v: expected the value at %#v, got %#v", path, parts, conf.DataSubTree)
+		}
+	}
+}
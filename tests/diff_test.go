@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Diff_1(t *testing.T) {
+	a := (&config.InitContext{}).FromBytes([]byte(`{
+		"name": "svc",
+		"port": 8080,
+		"ratio": 1,
+		"tls": "off",
+		"servers": ["a", "b", "c"],
+		"old": true
+	}`)).Load()
+	b := (&config.InitContext{}).FromBytes([]byte(`
+name: svc2
+port: 8080
+ratio: 1.0
+tls:
+  enabled: false
+servers: [a, x]
+new: 1
+`)).Load()
+
+	var changes []string
+	for _, ch := range a.Diff(b) {
+		changes = append(changes, ch.String())
+	}
+	expected := []string{
+		`"name": modified svc -> svc2`,
+		`"new": added 1`,
+		`"old": removed true`,
+		`"servers.1": modified b -> x`,
+		`"servers.2": removed c`,
+		`"tls": type-changed off -> map[enabled:false]`,
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("unexpected %#v", changes)
+	}
+	if ch := a.Diff(b)[5]; ch.Kind != config.ChangeKind_TypeChanged || !reflect.DeepEqual(ch.Path, []string{"tls"}) {
+		t.Fatalf("unexpected %v", ch)
+	}
+	if changes := a.Diff(a); len(changes) != 0 {
+		t.Fatalf("unexpected %v", changes)
+	}
+	if changes := a.P("servers").Diff(b.P("servers")); len(changes) != 2 || changes[0].Path[0] != "1" {
+		t.Fatalf("unexpected %v", changes)
+	}
+}
+
+func Test_MergePatch_1(t *testing.T) {
+	a := (&config.InitContext{}).FromBytes([]byte(`{
+		"name": "svc",
+		"limits": {"rps": 100, "burst": 10, "same": {"x": 1}},
+		"servers": ["a", "b"],
+		"old": true
+	}`)).Load()
+	b := (&config.InitContext{}).FromBytes([]byte(`{
+		"name": "svc",
+		"limits": {"rps": 200, "same": {"x": 1}},
+		"servers": ["a"],
+		"new": {"k": "v"}
+	}`)).Load()
+
+	patch := a.MergePatch(b)
+	var v interface{}
+	if err := json.Unmarshal(patch, &v); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"limits":  map[string]interface{}{"rps": 200.0, "burst": nil},
+		"servers": []interface{}{"a"},
+		"new":     map[string]interface{}{"k": "v"},
+		"old":     nil,
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected %s", patch)
+	}
+
+	var err error
+	a.Err(&err).ApplyMergePatch(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := a.Diff(b); len(changes) != 0 {
+		t.Fatalf("unexpected %v", changes)
+	}
+
+	a.P("limits").Err(&err).ApplyMergePatch([]byte(`{"rps": null, "burst": {"max": 5}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Has("limits", "rps") || a.P("limits", "burst", "max").Int() != 5 {
+		t.Fatalf("unexpected %v", a.P("limits").Map())
+	}
+
+	a.Err(&err).ApplyMergePatch([]byte(`{`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
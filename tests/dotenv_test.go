@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_DotEnv_1(t *testing.T) {
+	var err error
+	dotEnv := (&config.InitContext{}).
+		FromFile("conf-test-files/dotenv/local.env").
+		Err(&err).
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := map[string]string{
+		"APP_SERVER_PORT": "9090",
+		"APP_SERVER_HOST": "localhost",
+		"APP_GREETING":    "Hello,\n\"world\"",
+		"APP_CERT":        "-----BEGIN-----\nabc\n-----END-----",
+		"APP_EMPTY":       "",
+	}
+	vars := dotEnv.MapString()
+	if len(vars) != len(expected) {
+		t.Fatalf("unexpected vars %v", vars)
+	}
+	for k, v := range expected {
+		if vars[k] != v {
+			t.Fatalf("%v: expected %q, got %q", k, v, vars[k])
+		}
+	}
+
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  port: 80
+  host: example.com
+`)).Load()
+
+	os.Setenv("APP_SERVER_HOST", "from-env")
+	defer os.Unsetenv("APP_SERVER_HOST")
+
+	conf.ExtendByDotEnv_WithPrefix(dotEnv, "app").ExtendByEnvs_WithPrefix("app")
+	if v, ok := conf.P("server", "port").DataSubTree.(int); !ok || v != 9090 {
+		t.Fatalf("unexpected port %#v", conf.P("server", "port").DataSubTree)
+	}
+	if v := conf.P("server", "host").String(); v != "from-env" {
+		t.Fatalf("unexpected host '%v'", v)
+	}
+}
+
+func Test_DotEnv_2_Unterminated(t *testing.T) {
+	var err error
+	(&config.InitContext{DotEnv: true}).
+		FromBytes([]byte("A=1\nB=\"abc\n")).
+		Err(&err).
+		Load()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	t.Log(err)
+}
+
+func Test_DotEnv_3_Export(t *testing.T) {
+	var err error
+	dotEnv := (&config.InitContext{DotEnv: true}).
+		FromBytes([]byte("export\tA=1\nexport   B=2\nexport=3\nexports=4\n")).
+		Err(&err).
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := map[string]string{"A": "1", "B": "2", "export": "3", "exports": "4"}
+	if vars := dotEnv.MapString(); !reflect.DeepEqual(vars, expected) {
+		t.Fatalf("unexpected vars %v", vars)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Envs_1_Typed(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+port: 80
+ratio: 0.5
+debug: false
+hosts: [a, b]
+ports: [1, 2]
+name: x
+`)).Load()
+
+	os.Setenv("ENVTEST_PORT", "8080")
+	os.Setenv("ENVTEST_RATIO", "1.5")
+	os.Setenv("ENVTEST_DEBUG", "true")
+	os.Setenv("ENVTEST_HOSTS", "c, d, e")
+	os.Setenv("ENVTEST_PORTS", "[3, 4]")
+	os.Setenv("ENVTEST_NAME", "y")
+	defer func() {
+		for _, k := range []string{"PORT", "RATIO", "DEBUG", "HOSTS", "PORTS", "NAME"} {
+			os.Unsetenv("ENVTEST_" + k)
+		}
+	}()
+
+	var err error
+	conf.Err(&err).ExtendByEnvs_WithPrefix("envtest")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := map[string]interface{}{
+		"port":  8080,
+		"ratio": 1.5,
+		"debug": true,
+		"hosts": []interface{}{"c", "d", "e"},
+		"ports": []interface{}{3, 4},
+		"name":  "y",
+	}
+	for k, v := range expected {
+		if got := conf.P(k).DataSubTree; !reflect.DeepEqual(got, v) {
+			t.Fatalf("%v: expected %#v, got %#v", k, v, got)
+		}
+	}
+
+	rendered, _ := config.RenderYaml(conf.DataSubTree)
+	t.Log(rendered)
+}
+
+func Test_Envs_2_BadValue(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`port: 80`)).Load()
+	os.Setenv("ENVTEST_PORT", "eighty")
+	defer os.Unsetenv("ENVTEST_PORT")
+
+	var err error
+	conf.Err(&err).ExtendByEnvs_WithPrefix("envtest")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	t.Log(err)
+	if v := conf.P("port").Int(); v != 80 {
+		t.Fatalf("expected the port to be left as is, got %v", v)
+	}
+
+	// the value may be a secret, so it's not in the error
+	conf = (&config.InitContext{}).FromBytes([]byte(`db: {port: 5432, hosts: [a]}`)).Load()
+	os.Setenv("ENVTEST_DB_PORT", "hunter2")
+	os.Setenv("ENVTEST_DB_HOSTS", "[hunter3")
+	defer os.Unsetenv("ENVTEST_DB_PORT")
+	defer os.Unsetenv("ENVTEST_DB_HOSTS")
+	for _, name := range []string{"ENVTEST_DB_HOSTS", "ENVTEST_DB_PORT"} {
+		err = nil
+		conf.Err(&err).ExtendByEnvs_WithPrefix("envtest")
+		if err == nil || strings.Contains(err.Error(), "hunter") || !strings.Contains(err.Error(), name) {
+			t.Fatalf("unexpected %v", err)
+		}
+		os.Unsetenv(name)
+	}
+}
+
+func Test_Envs_3_Mapper(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  read-timeout: 1s
+  port: 80
+a:
+  s-d: 1
+  sd: 2
+`)).Load()
+
+	m := &config.EnvMapper{
+		Prefix:          "APP__",
+		Separator:       "__",
+		DashReplacement: "_",
+	}
+	if name := m.Name([]string{"server", "read-timeout"}); name != "APP__SERVER__READ_TIMEOUT" {
+		t.Fatalf("unexpected name '%v'", name)
+	}
+	if p := m.Path("APP__SERVER__READ_TIMEOUT"); !reflect.DeepEqual(p, []string{"server", "read-timeout"}) {
+		t.Fatalf("unexpected path %v", p)
+	}
+
+	collisions := config.DefaultEnvMapper("").Collisions(conf)
+	if len(collisions["A_SD"]) != 2 {
+		t.Fatalf("expected a collision, got %v", collisions)
+	}
+	if collisions = m.Collisions(conf); len(collisions) != 0 {
+		t.Fatalf("expected no collisions, got %v", collisions)
+	}
+
+	os.Setenv("APP__SERVER__READ_TIMEOUT", "5s")
+	os.Setenv("APP__SERVER__PORT", "8080")
+	os.Setenv("APP__SERVER__TLS__CERT", "server.pem")
+	defer func() {
+		os.Unsetenv("APP__SERVER__READ_TIMEOUT")
+		os.Unsetenv("APP__SERVER__PORT")
+		os.Unsetenv("APP__SERVER__TLS__CERT")
+	}()
+
+	conf.ExtendByEnvsV2_WithMapper(m)
+	if v := conf.P("server", "read-timeout").String(); v != "5s" {
+		t.Fatalf("unexpected read-timeout '%v'", v)
+	}
+	if v, ok := conf.P("server", "port").DataSubTree.(int); !ok || v != 8080 {
+		t.Fatalf("unexpected port %#v", conf.P("server", "port").DataSubTree)
+	}
+	if v := conf.P("server", "tls", "cert").String(); v != "server.pem" {
+		t.Fatalf("unexpected cert '%v'", v)
+	}
+
+	// the collisions go to the logger of the mapper
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	m2 := config.DefaultEnvMapper("")
+	m2.Logger = &logger
+	conf.ExtendByEnvs_WithMapper(m2)
+	if !strings.Contains(buf.String(), "A_SD") {
+		t.Fatalf("expected the collision logged, got %q", buf.String())
+	}
+
+	// but not by default, as the removed dashes make them common
+	buf.Reset()
+	globalLogger := log.Logger
+	log.Logger = logger
+	conf.ExtendByEnvs_WithPrefix("")
+	log.Logger = globalLogger
+	if buf.Len() != 0 {
+		t.Fatalf("expected nothing logged, got %q", buf.String())
+	}
+
+	var err error
+	conf.Err(&err).ExtendByEnvsV2_WithMapper(&config.EnvMapper{})
+	if err == nil {
+		t.Fatal("expected an error for the empty prefix")
+	}
+}
+
+func Test_Envs_4_ListPrefix(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+hosts: [a, b]
+hosts_extra: x
+`)).Load()
+	os.Setenv("ENVTEST_HOSTS", "c")
+	os.Setenv("ENVTEST_HOSTS_0", "d")
+	os.Setenv("ENVTEST_HOSTS_EXTRA", "y")
+	defer func() {
+		for _, k := range []string{"HOSTS", "HOSTS_0", "HOSTS_EXTRA"} {
+			os.Unsetenv("ENVTEST_" + k)
+		}
+	}()
+
+	conf.ExtendByEnvs_WithPrefix("envtest")
+	if v := conf.P("hosts").ListString(); !reflect.DeepEqual(v, []string{"c"}) {
+		t.Fatalf("unexpected %v", v)
+	}
+	if v := conf.P("hosts_extra").String(); v != "y" {
+		t.Fatalf("unexpected %v", v)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_ErrCollector_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  port: http
+  timeout: 5s
+  hosts: [a, b]
+db:
+  pool: 5
+`)).Load()
+
+	ec := &config.ErrCollector{}
+	c := conf.Collect(ec)
+	_ = c.P("server", "port").Int()
+	_ = c.P("server", "timeout").Duration()
+	_ = c.P("server", "nope").P("deeper").String()
+	_ = c.P("server", "hosts").Bool()
+	_ = c.P("db", "pool").Int()
+	retries := c.P("db", "retries").Int(func() int { return 3 })
+	if retries != 3 {
+		t.Fatalf("unexpected retries %v", retries)
+	}
+
+	errs := ec.Errors()
+	for _, err := range errs {
+		t.Logf("%v", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", len(errs))
+	}
+	err := ec.Err()
+	if !errors.Is(err, config.ErrNotFound) || !errors.Is(err, config.ErrTypeMismatch) {
+		t.Fatalf("unexpected %v", err)
+	}
+	var tme *config.TypeMismatchError
+	if !errors.As(err, &tme) || !reflect.DeepEqual(tme.Path, []string{"server", "port"}) || tme.Expected != "int" {
+		t.Fatalf("unexpected %v", tme)
+	}
+	// without relying on the Unwrap() []error, which needs Go 1.20
+	me := err.(*config.MultiError)
+	var nfe *config.NotFoundError
+	if !me.Is(config.ErrNotFound) || me.Is(config.ErrIndexOutOfRange) || !me.As(&nfe) {
+		t.Fatalf("unexpected %v", err)
+	}
+
+	ec.Reset()
+	if ec.Err() != nil {
+		t.Fatalf("expected no errors after the reset")
+	}
+}
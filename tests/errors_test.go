@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Errors_1_PathErrors(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  port: 8080
+  name: abc
+  hosts: [a, b]
+`)).Load()
+
+	var err error
+	conf.Err(&err).P("server").P("nope").String()
+	var nfe *config.NotFoundError
+	if !errors.Is(err, config.ErrNotFound) || !errors.As(err, &nfe) ||
+		!reflect.DeepEqual(nfe.Path, []string{"server", "nope"}) {
+		t.Fatalf("unexpected %v", err)
+	}
+
+	err = nil
+	conf.Err(&err).P("server", "hosts").P("5").String()
+	var ioore *config.IndexOutOfRangeError
+	if !errors.Is(err, config.ErrIndexOutOfRange) || !errors.As(err, &ioore) ||
+		!reflect.DeepEqual(ioore.Path, []string{"server", "hosts", "5"}) || ioore.Len != 2 {
+		t.Fatalf("unexpected %v", err)
+	}
+
+	err = nil
+	conf.Err(&err).P("server").P("hosts", "x").String()
+	if !errors.Is(err, config.ErrInvalidPath) {
+		t.Fatalf("unexpected %v", err)
+	}
+	err = nil
+	conf.Err(&err).P("server", "port", "x").String()
+	if !errors.Is(err, config.ErrInvalidPath) {
+		t.Fatalf("unexpected %v", err)
+	}
+	if errors.Is(err, config.ErrNotFound) {
+		t.Fatalf("not a ErrNotFound: %v", err)
+	}
+}
+
+func Test_Errors_2_TypeMismatch(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  port: 8080
+  name: abc
+  hosts: [a, b]
+`)).Load()
+
+	var err error
+	conf.Err(&err).P("server").P("hosts").Int()
+	var tme *config.TypeMismatchError
+	if !errors.Is(err, config.ErrTypeMismatch) || !errors.As(err, &tme) ||
+		!reflect.DeepEqual(tme.Path, []string{"server", "hosts"}) || tme.Got != "[]interface {}" {
+		t.Fatalf("unexpected %v", err)
+	}
+	t.Logf("%v", err)
+
+	err = nil
+	conf.Err(&err).P("server", "name").Int()
+	var ne *strconv.NumError
+	if !errors.As(err, &tme) || tme.Expected != "int" || tme.Got != "string" || !errors.As(err, &ne) {
+		t.Fatalf("unexpected %v", err)
+	}
+	t.Logf("%v", err)
+}
+
+func Test_Errors_3_NegativeIndex(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`servers: [{host: a}]`)).Load()
+
+	var err error
+	conf.Err(&err).P("servers", "-1")
+	var ipe *config.InvalidPathError
+	if !errors.As(err, &ipe) || !reflect.DeepEqual(ipe.Path, []string{"servers", "-1"}) {
+		t.Fatalf("unexpected %v", err)
+	}
+
+	err = nil
+	conf.Err(&err).Set([]string{"servers", "-1", "host"}, "b")
+	if !errors.Is(err, config.ErrInvalidPath) {
+		t.Fatalf("unexpected %v", err)
+	}
+	err = nil
+	conf.P("servers").Err(&err).Set([]string{"3"}, "b")
+	if !errors.Is(err, config.ErrIndexOutOfRange) {
+		t.Fatalf("unexpected %v", err)
+	}
+	if v := conf.P("servers", "0", "host").String(); v != "a" {
+		t.Fatalf("unexpected %v", v)
+	}
+
+	// a list about to be created, under a missing key, or a nil list item
+	for _, path := range [][]string{{"new", "-3"}, {"new", "-3", "x"}, {"servers", "0", "ports", "+1"}} {
+		err = nil
+		conf.Err(&err).Set(path, 1)
+		if !errors.Is(err, config.ErrInvalidPath) {
+			t.Fatalf("%v: unexpected %v", path, err)
+		}
+	}
+	if conf.Has("new") || conf.Has("servers", "0", "ports") {
+		t.Fatalf("unexpected %v", conf.DataSubTree)
+	}
+}
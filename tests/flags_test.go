@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_FlagBinder_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  read-timeout: 1s
+  port: 80
+  hosts: [a, b]
+verbose: false
+ratio: 0.5
+`)).Load()
+
+	fb := config.NewFlagBinder(conf)
+	positional, err := fb.Parse([]string{
+		"run",
+		"--server.read-timeout=5s",
+		"--server-port", "8080",
+		"--verbose",
+		"--server.hosts", "c", "--server-hosts=d",
+		"--ratio=1.5",
+		"--", "--not-an-option",
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !reflect.DeepEqual(positional, []string{"run", "--not-an-option"}) {
+		t.Fatalf("unexpected positional args %v", positional)
+	}
+	expected := map[string]interface{}{
+		"server.read-timeout": "5s",
+		"server.port":         8080,
+		"server.hosts":        []interface{}{"c", "d"},
+		"verbose":             true,
+		"ratio":               1.5,
+	}
+	for path, v := range expected {
+		if got := conf.P(config.SplitPathToParts(path)...).DataSubTree; !reflect.DeepEqual(got, v) {
+			t.Fatalf("%v: expected %#v, got %#v", path, v, got)
+		}
+	}
+}
+
+func Test_FlagBinder_2_Errors(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  timeout: 1s
+  port: 80
+`)).Load()
+
+	var help bytes.Buffer
+	fb := config.NewFlagBinder(conf, func(opts *config.FlagBinder_Options) {
+		opts.Name = "app"
+		opts.Descriptions["server.port"] = "port to listen on"
+		opts.Output = &help
+	})
+
+	_, err := fb.Parse([]string{"--server.timout=5s"})
+	if err == nil || !strings.Contains(err.Error(), "did you mean --server.timeout?") {
+		t.Fatalf("expected a suggestion, got %v", err)
+	}
+	_, err = fb.Parse([]string{"--server.port=eighty"})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	_, err = fb.Parse([]string{"--server.timeout=soon"})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	// the single-dash options aren't positional, a typo is reported
+	_, err = fb.Parse([]string{"-v"})
+	if err == nil || !strings.Contains(err.Error(), "unknown option -v") {
+		t.Fatalf("expected an unknown option, got %v", err)
+	}
+	_, err = fb.Parse([]string{"-server.port=8080"})
+	if err == nil || !strings.Contains(err.Error(), "did you mean --server.port?") {
+		t.Fatalf("expected a suggestion, got %v", err)
+	}
+	positional, err := fb.Parse([]string{"-", "-5", "--server.port", "-1"})
+	if err != nil || !reflect.DeepEqual(positional, []string{"-", "-5"}) || conf.P("server", "port").Int() != -1 {
+		t.Fatalf("unexpected %v %v", positional, err)
+	}
+
+	_, err = fb.Parse([]string{"--help"})
+	if !errors.Is(err, config.ErrHelp) || !strings.Contains(help.String(), "port to listen on") {
+		t.Fatalf("expected the help, got %v\n%v", err, help.String())
+	}
+	t.Logf("\n%v", help.String())
+}
+
+func Test_FlagBinder_3_Conflicts(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		conf := (&config.InitContext{}).FromBytes([]byte(`
+a:
+  b-c: 1
+a-b:
+  c: 2
+x:
+  y: 1
+x-y: 5
+`)).Load()
+		fb := config.NewFlagBinder(conf)
+
+		_, err := fb.Parse([]string{"--a-b-c=3"})
+		if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+			t.Fatalf("expected an ambiguity error, got %v", err)
+		}
+		if _, err = fb.Parse([]string{"--a.b-c=3", "--a-b.c=4"}); err != nil {
+			t.Fatal(err)
+		}
+		if conf.P("a", "b-c").Int() != 3 || conf.P("a-b", "c").Int() != 4 {
+			t.Fatalf("unexpected %v", conf.DataSubTree)
+		}
+
+		// the name takes precedence over the alias
+		if _, err = fb.Parse([]string{"--x-y=6"}); err != nil {
+			t.Fatal(err)
+		}
+		if conf.P("x-y").Int() != 6 || conf.P("x", "y").Int() != 1 {
+			t.Fatalf("unexpected %v", conf.DataSubTree)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Include_1_Yaml(t *testing.T) {
+	var err error
+	conf := (&config.InitContext{}).
+		FromFile("conf-test-files/include/main.yaml").
+		Err(&err).
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v := conf.P("listeners", "admin", "tls", "cert").String(); v != "server.pem" {
+		t.Fatalf("expected server.pem, got '%v'", v)
+	}
+	if v := conf.P("listeners", "public", "tls", "ciphers", "1").String(); v != "TLS_AES_256_GCM_SHA384" {
+		t.Fatalf("unexpected cipher '%v'", v)
+	}
+}
+
+func Test_Include_2_Json(t *testing.T) {
+	var err error
+	conf := (&config.InitContext{}).
+		FromFile("conf-test-files/include/main.json").
+		Err(&err).
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v := conf.P("listeners", "public", "tls", "key").String(); v != "server.key" {
+		t.Fatalf("expected server.key, got '%v'", v)
+	}
+}
+
+func Test_Include_3_Cycle(t *testing.T) {
+	var err error
+	(&config.InitContext{}).
+		FromFile("conf-test-files/include/loop-a.yaml").
+		Err(&err).
+		Load()
+	if !errors.Is(err, config.ErrIncludeCycle) {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+}
+
+func Test_Include_4_FS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/app.yaml":     {Data: []byte("db: !include db/main.yaml\n")},
+		"etc/db/main.yaml": {Data: []byte("addr: localhost\nauth: {$include: auth.json}\n")},
+		"etc/db/auth.json": {Data: []byte(`{"user": "app"}`)},
+	}
+	var err error
+	conf := (&config.InitContext{}).
+		WithFS(fsys).
+		FromFile("etc/app.yaml").
+		Err(&err).
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v := conf.P("db", "auth", "user").String(); v != "app" {
+		t.Fatalf("expected app, got '%v'", v)
+	}
+}
+
+func Test_Include_5_FromBytes(t *testing.T) {
+	var err error
+	(&config.InitContext{}).
+		FromBytes([]byte("tls: !include conf-test-files/include/nonexistent.yaml\n")).
+		Err(&err).
+		Load()
+	var ie *config.IncludeError
+	if !errors.As(err, &ie) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected an include error, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Interpolation_1(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INTERPOLATION_TEST_USER", "bob")
+
+	var err error
+	conf := (&config.InitContext{}).
+		FromBytes([]byte(`
+base-url: https://${host}:${port}
+host: example.com
+port: 8080
+api:
+  url: ${base-url}/api
+  port: ${port}
+  user: ${env:INTERPOLATION_TEST_USER}
+  home: ${env:INTERPOLATION_TEST_NOT_SET:-/tmp}
+  password: ${file:` + secret + `}
+  literal: $${host}
+`)).
+		Err(&err).
+		WithInterpolation().
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := map[string]string{
+		"url":      "https://example.com:8080/api",
+		"user":     "bob",
+		"home":     "/tmp",
+		"password": "s3cr3t",
+		"literal":  "${host}",
+	}
+	for k, v := range expected {
+		if got := conf.P("api", k).String(); got != v {
+			t.Fatalf("api.%v: expected '%v', got '%v'", k, v, got)
+		}
+	}
+	if v, ok := conf.P("api", "port").DataSubTree.(int); !ok || v != 8080 {
+		t.Fatalf("api.port: expected int 8080, got %#v", conf.P("api", "port").DataSubTree)
+	}
+	if v := conf.P("api", "url").Raw(); v != "${base-url}/api" {
+		t.Fatalf("api.url: expected the template, got '%v'", v)
+	}
+
+	// again, from the templates, with the changed values taken into account
+	conf.Set([]string{"port"}, 9090)
+	conf.Err(&err).Interpolate()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected["url"] = "https://example.com:9090/api"
+	for k, v := range expected {
+		if got := conf.P("api", k).String(); got != v {
+			t.Fatalf("api.%v: expected '%v', got '%v'", k, v, got)
+		}
+	}
+}
+
+func Test_Interpolation_2_Cycle(t *testing.T) {
+	var err error
+	(&config.InitContext{}).
+		FromBytes([]byte(`{"a": "${b}", "b": "x${c}", "c": "${a}"}`)).
+		Err(&err).
+		WithInterpolation().
+		Load()
+	if err == nil {
+		t.Fatalf("expected a cycle error")
+	}
+}
+
+func Test_Interpolation_3_FS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.yaml":   {Data: []byte("password: ${file:secrets/db}\n")},
+		"secrets/db": {Data: []byte("s3cr3t\n")},
+	}
+	var err error
+	conf := (&config.InitContext{}).WithFS(fsys).FromFile("app.yaml").Err(&err).WithInterpolation().Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v := conf.P("password").String(); v != "s3cr3t" {
+		t.Fatalf("unexpected '%v'", v)
+	}
+}
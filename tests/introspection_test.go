@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Introspection_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`{
+		"name": "svc",
+		"port": 8080,
+		"ratio": 0.5,
+		"enabled": true,
+		"nothing": null,
+		"servers": [{"host": "a"}, {"host": "b"}],
+		"empty": {}
+	}`)).Load()
+
+	kinds := map[string]config.Kind{
+		"name":    config.Kind_String,
+		"port":    config.Kind_Number,
+		"ratio":   config.Kind_Number,
+		"enabled": config.Kind_Bool,
+		"nothing": config.Kind_Null,
+		"servers": config.Kind_List,
+		"empty":   config.Kind_Map,
+	}
+	for k, kind := range kinds {
+		if v := conf.P(k).Kind(); v != kind {
+			t.Fatalf("%v: unexpected %v", k, v)
+		}
+	}
+	if v := conf.Kind(); v != config.Kind_Map {
+		t.Fatalf("unexpected %v", v)
+	}
+
+	if !conf.Has("servers", "1", "host") || !conf.Has("nothing") || !conf.Has() {
+		t.Fatal("expected to exist")
+	}
+	if conf.Has("servers", "2") || conf.Has("name", "x") || conf.Has("missing") ||
+		conf.Has("servers", "-1") || conf.Has("servers", "x") {
+		t.Fatal("expected to not exist")
+	}
+
+	keys := []string{"empty", "enabled", "name", "nothing", "port", "ratio", "servers"}
+	if v := conf.Keys(); !reflect.DeepEqual(v, keys) {
+		t.Fatalf("unexpected %v", v)
+	}
+	if v := conf.P("servers").Keys(); v != nil {
+		t.Fatalf("unexpected %v", v)
+	}
+	if conf.Len() != 7 || conf.P("servers").Len() != 2 || conf.P("empty").Len() != 0 || conf.P("name").Len() != 0 {
+		t.Fatal("unexpected Len()")
+	}
+}
+
+func Test_Introspection_Walk(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`{
+		"b": {"y": 2, "x": 1},
+		"a": [10, {"skip": {"deep": 1}}],
+		"c": "s"
+	}`)).Load()
+
+	var visited []string
+	err := conf.P("a").Walk(func(path []string, node *config.Config) error {
+		visited = append(visited, strings.Join(path, ".")+"="+string(node.Kind()))
+		if len(path) > 0 && path[len(path)-1] == "skip" {
+			return config.SkipSubtree
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"=list", "0=number", "1=map", "1.skip=map"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("unexpected %v", visited)
+	}
+
+	// the nodes have their absolute locations
+	visited = nil
+	conf.Walk(func(path []string, node *config.Config) error {
+		if node.Kind() != config.Kind_Map && node.Kind() != config.Kind_List {
+			visited = append(visited, strings.Join(node.GetCurrentLocationPlusPath(), "."))
+		}
+		return nil
+	})
+	expected = []string{"a.0", "a.1.skip.deep", "b.x", "b.y", "c"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("unexpected %v", visited)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = conf.Walk(func(path []string, node *config.Config) error {
+		count++
+		if len(path) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 3 {
+		t.Fatalf("unexpected %v, %v", err, count)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+var jsonPathTestData = []byte(`
+clusters:
+  - name: c1
+    region: eu
+    size: 3
+    nodes:
+      - addr: 10.0.0.1
+      - addr: 10.0.0.2
+  - name: c2
+    region: us
+    size: 5
+    nodes:
+      - addr: 10.1.0.1
+  - name: c3
+    region: eu
+    size: 7
+    canary: true
+    nodes:
+      - addr: 10.2.0.1
+default-region: eu
+`)
+
+func Test_JSONPath_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes(jsonPathTestData).Load()
+
+	cases := []struct {
+		expr     string
+		expected []string
+	}{
+		{"$.clusters[?(@.region=='eu')].nodes[*].addr", []string{"clusters.0.nodes.0.addr", "clusters.0.nodes.1.addr", "clusters.2.nodes.0.addr"}},
+		{"$.clusters[?(@.region == $['default-region'] && @.size > 3)].name", []string{"clusters.2.name"}},
+		{"$.clusters[?(@.size < 4 || @.canary)].name", []string{"clusters.0.name", "clusters.2.name"}},
+		{"$.clusters[?(!@.canary)].name", []string{"clusters.0.name", "clusters.1.name"}},
+		{"$..addr", []string{"clusters.0.nodes.0.addr", "clusters.0.nodes.1.addr", "clusters.1.nodes.0.addr", "clusters.2.nodes.0.addr"}},
+		{"$.clusters[0,2].name", []string{"clusters.0.name", "clusters.2.name"}},
+		{"$.clusters[-1]['name','region']", []string{"clusters.2.name", "clusters.2.region"}},
+		{"$.clusters[1:].name", []string{"clusters.1.name", "clusters.2.name"}},
+		{"$.clusters[::2].name", []string{"clusters.0.name", "clusters.2.name"}},
+		{"$..nodes[0].addr", []string{"clusters.0.nodes.0.addr", "clusters.1.nodes.0.addr", "clusters.2.nodes.0.addr"}},
+		{"$.nope.*", []string{}},
+		{"$.clusters[?(@.nodes[-1].addr=='10.0.0.2')].name", []string{"clusters.0.name"}},
+		{"$.clusters[?(@.nodes[-2])].name", []string{"clusters.0.name"}},
+		{"$.clusters[?(@.nodes[-5])].name", []string{}},
+	}
+	for _, tc := range cases {
+		if paths := queryPaths(conf.JSONPath(tc.expr)); !reflect.DeepEqual(paths, tc.expected) {
+			t.Fatalf("%v: expected %v, got %v", tc.expr, tc.expected, paths)
+		}
+	}
+
+	addrs := []string{}
+	for _, c := range conf.P("clusters").JSONPath("$[?(@.region=='eu')].nodes[0].addr") {
+		addrs = append(addrs, c.String())
+	}
+	if !reflect.DeepEqual(addrs, []string{"10.0.0.1", "10.2.0.1"}) {
+		t.Fatalf("unexpected %v", addrs)
+	}
+}
+
+func Test_JSONPath_2_Errors(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes(jsonPathTestData).Load()
+	for _, expr := range []string{"clusters", "$.clusters[", "$.clusters[?(@.size >)]", "$.clusters[1:2:-1]", "$.clusters['a"} {
+		var err error
+		conf.Err(&err).JSONPath(expr)
+		var jpe *config.JSONPathError
+		if !errors.As(err, &jpe) || !errors.Is(err, config.ErrInvalidPath) {
+			t.Fatalf("%v: unexpected %v", expr, err)
+		}
+		t.Logf("%v", err)
+	}
+}
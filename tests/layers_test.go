@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Layers_1(t *testing.T) {
+	os.Setenv("LAYERS_SERVER_PORT", "9090")
+	defer os.Unsetenv("LAYERS_SERVER_PORT")
+
+	var err error
+	conf, report := (&config.Layers{}).
+		Defaults(map[string]interface{}{
+			"server":  map[string]interface{}{"port": 80, "timeout": "5s"},
+			"db":      map[string]interface{}{"pool": 1},
+			"verbose": false,
+		}).
+		File("conf-test-files/layers/app.yaml").
+		Dir("conf-test-files/layers/conf.d").
+		DotEnv("conf-test-files/layers/nonexistent.env", config.DefaultEnvMapper("layers")).
+		Env(config.DefaultEnvMapper("layers")).
+		Args("app", "-verbose=true").
+		Set([]string{"log-level"}, "debug").
+		Err(&err).
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	t.Logf("\n%v", report)
+
+	expected := map[string]string{
+		"server.port":    "env",
+		"server.timeout": "defaults",
+		"server.host":    "conf-test-files/layers/app.yaml",
+		"db.addr":        "conf-test-files/layers/conf.d",
+		"db.pool":        "conf-test-files/layers/conf.d",
+		"verbose":        "args",
+		"log-level":      "set log-level",
+	}
+	for path, layer := range expected {
+		if w := report.Winner(config.SplitPathToParts(path)...); w != layer {
+			t.Fatalf("%v: expected the layer '%v', got '%v'", path, layer, w)
+		}
+	}
+	if v := conf.P("server", "port").Int(); v != 9090 {
+		t.Fatalf("unexpected port %v", v)
+	}
+	if v := conf.P("db", "pool").Int(); v != 20 {
+		t.Fatalf("unexpected pool %v", v)
+	}
+	if v := conf.P("verbose").Bool(); !v {
+		t.Fatalf("expected verbose")
+	}
+}
+
+func Test_Layers_2_Error(t *testing.T) {
+	var err error
+	conf, _ := (&config.Layers{}).
+		File("conf-test-files/layers/nonexistent.yaml").
+		Err(&err).
+		Load()
+	var le *config.LayerError
+	if conf != nil || !errors.As(err, &le) {
+		t.Fatalf("expected *LayerError, got %v", err)
+	}
+}
+
+func Test_Layers_3_TypedArgs(t *testing.T) {
+	var err error
+	conf, report := (&config.Layers{}).
+		Defaults(map[string]interface{}{"verbose": false}).
+		File("conf-test-files/layers/app.yaml").
+		Args("app", "--verbose", "--server.port=9000", "--log-level", "debug").
+		Err(&err).
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v, ok := conf.P("verbose").DataSubTree.(bool); !ok || !v {
+		t.Fatalf("unexpected verbose %#v", conf.P("verbose").DataSubTree)
+	}
+	if v, ok := conf.P("server", "port").DataSubTree.(int); !ok || v != 9000 {
+		t.Fatalf("unexpected port %#v", conf.P("server", "port").DataSubTree)
+	}
+	if v := conf.P("log-level").String(); v != "debug" {
+		t.Fatalf("unexpected log-level %v", v)
+	}
+	if w := report.Winner("server", "port"); w != "args" {
+		t.Fatalf("unexpected winner %v", w)
+	}
+
+	// the options of the application, and the --help, are left to it
+	conf, _ = (&config.Layers{}).
+		File("conf-test-files/layers/app.yaml").
+		Args("app", "--dry-run", "-n", "--help", "serve", "-server.port=9001").
+		Err(&err).
+		Load()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if v := conf.P("server", "port").Int(); v != 9001 {
+		t.Fatalf("unexpected port %v", v)
+	}
+
+	(&config.Layers{}).
+		File("conf-test-files/layers/app.yaml").
+		Args("app", "--server.port=eighty").
+		Err(&err).
+		Load()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
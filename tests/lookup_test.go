@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Lookup_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+server:
+  port: 8080
+  name: abc
+  timeout: 5s
+  hosts: [a, b]
+`)).Load()
+
+	// no Err, Ok or U(), and nothing panics
+	if port, ok := conf.Lookup("server", "port").Int(); !ok || port != 8080 {
+		t.Fatalf("unexpected port %v", port)
+	}
+	if _, ok := conf.Lookup("server", "nope").Int(); ok {
+		t.Fatalf("expected not ok")
+	}
+	if _, ok := conf.Lookup("server", "name").Int(); ok {
+		t.Fatalf("expected not ok")
+	}
+	if d, err := conf.Lookup("server").Lookup("timeout").TryDuration(); err != nil || d != 5*time.Second {
+		t.Fatalf("unexpected %v, %v", d, err)
+	}
+	if _, err := conf.Lookup("server", "hosts").TryInt(); !errors.Is(err, config.ErrTypeMismatch) {
+		t.Fatalf("unexpected %v", err)
+	}
+	if _, err := conf.Lookup("server", "nope").Lookup("deeper").TryString(); !errors.Is(err, config.ErrNotFound) {
+		t.Fatalf("unexpected %v", err)
+	}
+	if conf.Lookup("server", "nope").Exists() || !conf.Lookup("server", "hosts", "1").Exists() {
+		t.Fatalf("unexpected Exists()")
+	}
+	if hosts, ok := conf.Lookup("server", "hosts").ListString(); !ok || len(hosts) != 2 {
+		t.Fatalf("unexpected hosts %v", hosts)
+	}
+
+	// the failures don't leak into the Err of the config
+	var err error
+	c := conf.Err(&err)
+	_, _ = c.Lookup("server", "nope").Int()
+	if err != nil {
+		t.Fatalf("unexpected %v", err)
+	}
+	if v := c.P("server", "name").String(); v != "abc" || err != nil {
+		t.Fatalf("unexpected %v, %v", v, err)
+	}
+}
+
+func Test_Lookup_2_Typed(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+ports: [80, 443]
+ratios: [0.5, 1]
+timeouts: [1s, 2m]
+servers: [{host: a}]
+limits: {rps: 100, burst: 10}
+weights: {a: 0.5}
+flags: {tls: true, debug: false}
+deadlines: {read: 5s}
+`)).Load()
+
+	if l, ok := conf.Lookup("ports").ListInt(); !ok || len(l) != 2 || l[1] != 443 {
+		t.Fatalf("unexpected %v", l)
+	}
+	if l, ok := conf.Lookup("ratios").ListFloat64(); !ok || len(l) != 2 || l[0] != 0.5 {
+		t.Fatalf("unexpected %v", l)
+	}
+	if l, ok := conf.Lookup("timeouts").ListDuration(); !ok || len(l) != 2 || l[1] != 2*time.Minute {
+		t.Fatalf("unexpected %v", l)
+	}
+	if m, ok := conf.Lookup("limits").MapInt(); !ok || m["burst"] != 10 {
+		t.Fatalf("unexpected %v", m)
+	}
+	if m, ok := conf.Lookup("weights").MapFloat64(); !ok || m["a"] != 0.5 {
+		t.Fatalf("unexpected %v", m)
+	}
+	if m, ok := conf.Lookup("flags").MapBool(); !ok || !m["tls"] || m["debug"] {
+		t.Fatalf("unexpected %v", m)
+	}
+	if m, ok := conf.Lookup("deadlines").MapDuration(); !ok || m["read"] != 5*time.Second {
+		t.Fatalf("unexpected %v", m)
+	}
+	if _, err := conf.Lookup("servers").TryListInt(); !errors.Is(err, config.ErrTypeMismatch) {
+		t.Fatalf("unexpected %v", err)
+	}
+	if _, err := conf.Lookup("ports").TryMapInt(); !errors.Is(err, config.ErrTypeMismatch) {
+		t.Fatalf("unexpected %v", err)
+	}
+
+	// a negative index is invalid, not a panic
+	if _, ok := conf.Lookup("servers", "-1").Map(); ok {
+		t.Fatalf("expected not ok")
+	}
+	if _, err := conf.Lookup("servers", "-1").TryMap(); !errors.Is(err, config.ErrInvalidPath) {
+		t.Fatalf("unexpected %v", err)
+	}
+}
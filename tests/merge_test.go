@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_MergeStrategies_1_Directives(t *testing.T) {
+	var err error
+	conf := (&config.InitContext{}).
+		FromFile("conf-test-files/merge/child.yaml").
+		Err(&err).
+		LoadWithParenting()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if v := conf.P("servers", "1", "port").Int(); v != 22 {
+		t.Fatalf("servers.1.port: expected 22, got %v", v)
+	}
+	if v := conf.P("servers", "2", "name").String(); v != "c" {
+		t.Fatalf("servers.2.name: expected 'c', got '%v'", v)
+	}
+	if v := conf.P("tags").ListString(); !reflect.DeepEqual(v, []string{"x", "y", "z"}) {
+		t.Fatalf("tags: got %v", v)
+	}
+	if v := conf.P("plugins").ListString(); !reflect.DeepEqual(v, []string{"p3"}) {
+		t.Fatalf("plugins: got %v", v)
+	}
+	if _, exists := conf.Map()["obsolete"]; exists {
+		t.Fatalf("obsolete: expected to be deleted")
+	}
+	if v := conf.P("tls").MapString(); !reflect.DeepEqual(v, map[string]string{"cert": "child.pem"}) {
+		t.Fatalf("tls: got %v", v)
+	}
+}
+
+func Test_MergeStrategies_2_Options(t *testing.T) {
+	c1 := (&config.InitContext{}).FromBytes([]byte(`{"a": [1, 2], "b": {"l": [1, 2]}}`)).Load()
+	c2 := (&config.InitContext{}).FromBytes([]byte(`{"a": [3], "b": {"l": [3]}}`)).Load()
+
+	c1.ExtendBy_v2(c2, func(opts *config.ExtendBy_Options) {
+		opts.ListStrategy = config.MergeStrategy_Prepend
+		opts.Rules = []config.MergeRule{
+			{Path: []string{"*", "l"}, Strategy: config.MergeStrategy_Append},
+		}
+	})
+
+	if v := c1.P("a").ListInt(); !reflect.DeepEqual(v, []int{3, 1, 2}) {
+		t.Fatalf("a: got %v", v)
+	}
+	if v := c1.P("b", "l").ListInt(); !reflect.DeepEqual(v, []int{1, 2, 3}) {
+		t.Fatalf("b.l: got %v", v)
+	}
+}
+
+func Test_MergeStrategies_3_Conflicts(t *testing.T) {
+	load := func() (*config.Config, *config.Config) {
+		c1 := (&config.InitContext{}).FromBytes([]byte(`{"a": {"x": 1}, "b": 1, "c": 1}`)).Load()
+		c2 := (&config.InitContext{}).FromBytes([]byte(`{"a": 2, "b": {"y": 2}, "c": 2}`)).Load()
+		return c1, c2
+	}
+
+	c1, c2 := load()
+	conflicts, err := c1.ExtendBy_v2_Strict(c2)
+	if err != nil || len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %v %v", conflicts, err)
+	}
+	if v := c1.P("a").Int(); v != 2 {
+		t.Fatalf("a: expected the child scalar to win, got %v", v)
+	}
+	if v := c1.P("b").Int(); v != 1 {
+		t.Fatalf("b: expected the parent scalar to stay, got %v", v)
+	}
+
+	c1, c2 = load()
+	c1.ExtendBy_v2_Strict(c2, func(opts *config.ExtendBy_Options) {
+		opts.ConflictPolicy = config.ConflictPolicy_ChildWins
+	})
+	if v := c1.P("b", "y").Int(); v != 2 {
+		t.Fatalf("b.y: expected 2, got %v", v)
+	}
+
+	c1, c2 = load()
+	conflicts, err = c1.ExtendBy_v2_Strict(c2, func(opts *config.ExtendBy_Options) {
+		opts.ConflictPolicy = config.ConflictPolicy_ParentWins
+	})
+	if err != nil || len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %v %v", conflicts, err)
+	}
+	if v := c1.P("a", "x").Int(); v != 1 {
+		t.Fatalf("a.x: expected the parent map to stay, got %v", v)
+	}
+	if v := c1.P("b").Int(); v != 1 {
+		t.Fatalf("b: expected the parent scalar to stay, got %v", v)
+	}
+	if v := c1.P("c").Int(); v != 2 {
+		t.Fatalf("c: expected the child scalar to win, not a conflict, got %v", v)
+	}
+
+	c1, c2 = load()
+	err = nil
+	c1.Err(&err).ExtendBy_v2(c2, func(opts *config.ExtendBy_Options) {
+		opts.ConflictPolicy = config.ConflictPolicy_Error
+	})
+	var mce *config.MergeConflictError
+	if !errors.As(err, &mce) || len(mce.Conflicts) != 2 {
+		t.Fatalf("expected *MergeConflictError, got %v", err)
+	}
+	if v := c1.P("c").Int(); v != 1 {
+		t.Fatalf("c: expected unchanged config, got %v", v)
+	}
+}
+
+func Test_MergeStrategies_4_ConflictOrigins(t *testing.T) {
+	load := func(fileName string) *config.Config {
+		return (&config.InitContext{}).FromFile("conf-test-files/merge/" + fileName).Load()
+	}
+	// the aggregate of two parents, as with the multi-level parenting
+	parents := load("conflict-p1.yaml")
+	parents.ExtendBy_v2(load("conflict-p2.yaml"))
+	conflicts, err := parents.ExtendBy_v2_Strict(load("conflict-child.yaml"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected := map[string]config.MergeConflict{
+		"a": {Type1: config.Kind_Map, Type2: config.Kind_Number, Origin1: "conflict-p1.yaml", Origin2: "conflict-child.yaml"},
+		"b": {Type1: config.Kind_Number, Type2: config.Kind_Map, Origin1: "conflict-p2.yaml", Origin2: "conflict-child.yaml"},
+		"c": {Type1: config.Kind_Map, Type2: config.Kind_Number, Origin1: "conflict-p2.yaml", Origin2: "conflict-child.yaml"},
+	}
+	if len(conflicts) != len(expected) {
+		t.Fatalf("unexpected conflicts %v", conflicts)
+	}
+	for _, mc := range conflicts {
+		e := expected[strings.Join(mc.Path, ".")]
+		if mc.Type1 != e.Type1 || mc.Type2 != e.Type2 ||
+			!strings.HasSuffix(mc.Origin1, e.Origin1) || !strings.HasSuffix(mc.Origin2, e.Origin2) {
+			t.Fatalf("unexpected conflict %v", mc)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rusriver/config/v2"
+)
+
+func testMigrations() *config.Migrations {
+	return config.NewMigrations().
+		AddVersion(0,
+			&config.Migration{From: []string{"listen"}, To: []string{"server", "listen"}},
+			&config.Migration{
+				From: []string{"timeout"},
+				To:   []string{"server", "timeout"},
+				Transform: func(v interface{}) (interface{}, error) {
+					return fmt.Sprintf("%vs", v), nil
+				},
+			},
+		).
+		AddVersion(1,
+			&config.Migration{From: []string{"servers", "*", "addr"}, To: []string{"servers", "*", "address"}},
+		).
+		Add(
+			&config.Migration{From: []string{"debug"}, To: []string{"log", "debug"}, Deprecation: "use log.debug"},
+		)
+}
+
+func Test_Migrations_1_Chain(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	for _, fileName := range []string{"v0.yaml", "v1.yaml"} {
+		var err error
+		conf := (&config.InitContext{}).
+			FromFile("conf-test-files/migrations/" + fileName).
+			WithLogger(&logger).
+			WithMigrations(testMigrations()).
+			Err(&err).
+			Load()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if v := conf.P("config-version").Int(); v != 2 {
+			t.Fatalf("unexpected version %v", v)
+		}
+		if v := conf.P("server", "timeout").Duration(); v.Seconds() < 5 {
+			t.Fatalf("unexpected timeout %v", v)
+		}
+		if v := conf.P("servers", "0", "address").String(); !strings.Contains(v, ":") {
+			t.Fatalf("unexpected address %v", v)
+		}
+		if conf.U().P("servers", "0", "addr").String() != "" || conf.U().P("listen").String() != "" {
+			t.Fatalf("the old keys are still there")
+		}
+	}
+	if !strings.Contains(buf.String(), `the key \"debug\" is deprecated: use log.debug`) {
+		t.Fatalf("no deprecation warning in %v", buf.String())
+	}
+}
+
+func Test_Migrations_2_Parenting(t *testing.T) {
+	var err error
+	conf := (&config.InitContext{}).
+		FromFile("conf-test-files/migrations/child.yaml").
+		WithMigrations(testMigrations()).
+		Err(&err).
+		LoadWithParenting()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	// the parent is of the version 0, and the child is of the version 1
+	expected := map[string]interface{}{
+		"listen":  ":8080",
+		"timeout": "10s",
+	}
+	if v := conf.P("server").Map(); !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected %v", v)
+	}
+	if v := conf.P("log", "debug").Bool(); !v {
+		t.Fatalf("expected log.debug")
+	}
+}
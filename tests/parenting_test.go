@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -106,3 +109,82 @@ func Test_20230620_4(t *testing.T) {
 	conf.PrintJson("conf after modification")
 	asd.PrintJson("asd after modification")
 }
+
+func Test_ConfigInheritance_2_Diamond(t *testing.T) {
+	var err error
+	conf := (&config.InitContext{}).
+		FromFile("conf-test-files/diamond/top.yaml").
+		Err(&err).
+		LoadWithParenting()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, k := range []string{"base", "left", "right", "top"} {
+		if v := conf.P(k).Int(); v != 1 {
+			t.Fatalf("%v: expected 1, got %v", k, v)
+		}
+	}
+	if v := conf.P("who").String(); v != "right" {
+		t.Fatalf("who: expected 'right', got '%v'", v)
+	}
+}
+
+func Test_ConfigInheritance_3_Cycle(t *testing.T) {
+	var err error
+	conf := (&config.InitContext{}).
+		FromFile("conf-test-files/cycle/a.yaml").
+		Err(&err).
+		LoadWithParenting()
+	if conf != nil {
+		t.Fatalf("expected nil config")
+	}
+	if !errors.Is(err, config.ErrParentingCycle) {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+	var pe *config.ParentingError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *ParentingError, got %T", err)
+	}
+	if len(pe.Chain) != 4 || pe.FileName != "conf-test-files/cycle/a.yaml" {
+		t.Fatalf("unexpected chain: %v", pe.Chain)
+	}
+}
+
+func Test_ConfigInheritance_4_MissingParent(t *testing.T) {
+	ok := true
+	conf := (&config.InitContext{}).
+		FromFile("conf-test-files/x1/X.yaml").
+		Ok(&ok).
+		LoadWithParenting()
+	if conf == nil || !ok {
+		t.Fatalf("expected success")
+	}
+
+	var err error
+	(&config.InitContext{}).
+		FromFile("conf-test-files/nonexistent.yaml").
+		Err(&err).
+		LoadWithParenting()
+	var pe *config.ParentingError
+	if !errors.As(err, &pe) || pe.FileName != "conf-test-files/nonexistent.yaml" {
+		t.Fatalf("expected *ParentingError naming the file, got %v", err)
+	}
+
+	// an existing child, pointing to a missing parent
+	err = nil
+	(&config.InitContext{}).
+		FromFile("conf-test-files/missing-parent/child.yaml").
+		Err(&err).
+		LoadWithParenting()
+	pe = nil
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *ParentingError, got %v", err)
+	}
+	chain := []string{"conf-test-files/missing-parent/child.yaml", "conf-test-files/missing-parent/nonexistent-parent.yaml"}
+	if !reflect.DeepEqual(pe.Chain, chain) || pe.FileName != chain[1] {
+		t.Fatalf("unexpected %v, %v", pe.FileName, pe.Chain)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rusriver/config/v2"
+)
+
+const patchTestConfig = `{
+	"name": "svc",
+	"servers": [{"host": "a"}, {"host": "b"}],
+	"limits": {"rps": 100}
+}`
+
+func Test_ApplyPatch_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(patchTestConfig)).Load()
+
+	var err error
+	conf.Err(&err).ApplyPatch([]byte(`[
+		{"op": "test", "path": "/name", "value": "svc"},
+		{"op": "add", "path": "/servers/1", "value": {"host": "c"}},
+		{"op": "add", "path": "/servers/-", "value": {"host": "d"}},
+		{"op": "remove", "path": "/servers/0"},
+		{"op": "replace", "path": "/limits/rps", "value": 200},
+		{"op": "copy", "from": "/limits", "path": "/defaults"},
+		{"op": "move", "from": "/name", "path": "/service~1name"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hosts := conf.P("servers").ListConfig()
+	if len(hosts) != 3 || hosts[0].P("host").String() != "c" || hosts[1].P("host").String() != "b" || hosts[2].P("host").String() != "d" {
+		t.Fatalf("unexpected %v", conf.P("servers").List())
+	}
+	if v := conf.P("limits", "rps").Int(); v != 200 {
+		t.Fatalf("unexpected %v", v)
+	}
+	if v := conf.P("defaults", "rps").Int(); v != 200 {
+		t.Fatalf("unexpected %v", v)
+	}
+	if conf.Has("name") || conf.Pointer("/service~1name").String() != "svc" {
+		t.Fatal("not moved")
+	}
+
+	// relative to the current location, also a list
+	servers := conf.P("servers")
+	servers.Err(&err).ApplyPatch([]byte(`[{"op": "remove", "path": "/0"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := conf.P("servers", "0", "host").String(); v != "b" {
+		t.Fatalf("unexpected %v", v)
+	}
+}
+
+func Test_ApplyPatch_Atomic(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(patchTestConfig)).Load()
+
+	cases := map[string]error{
+		`[{"op": "replace", "path": "/name", "value": "x"}, {"op": "test", "path": "/limits/rps", "value": 1}]`: config.ErrPatchTestFailed,
+		`[{"op": "replace", "path": "/name", "value": "x"}, {"op": "remove", "path": "/nope"}]`:                 config.ErrNotFound,
+		`[{"op": "add", "path": "/servers/5", "value": 1}]`:                                                     config.ErrIndexOutOfRange,
+		`[{"op": "add", "path": "/servers/01", "value": 1}]`:                                                    config.ErrInvalidPath,
+		`[{"op": "move", "from": "/limits", "path": "/limits/x"}]`:                                              config.ErrInvalidPath,
+		`[{"op": "add", "path": "name", "value": 1}]`:                                                           config.ErrInvalidPath,
+		`[{"op": "replace", "path": "/servers/-1", "value": 1}]`:                                                config.ErrInvalidPath,
+		`[{"op": "replace", "path": "/servers/+1/host", "value": 1}]`:                                           config.ErrInvalidPath,
+		`[{"op": "test", "path": "/servers/-1", "value": 1}]`:                                                   config.ErrInvalidPath,
+		`[{"op": "test", "path": "/servers/01/host", "value": "b"}]`:                                            config.ErrInvalidPath,
+		`[{"op": "copy", "from": "/servers/-1", "path": "/x"}]`:                                                 config.ErrInvalidPath,
+		`[{"op": "copy", "from": "/name", "path": "/servers/-1/x"}]`:                                            config.ErrInvalidPath,
+		`[{"op": "move", "from": "/servers/-1", "path": "/x"}]`:                                                 config.ErrInvalidPath,
+		`[{"op": "remove", "path": "/servers/-1"}]`:                                                             config.ErrInvalidPath,
+	}
+	for patch, expected := range cases {
+		var err error
+		conf.Err(&err).ApplyPatch([]byte(patch))
+		if !errors.Is(err, expected) {
+			t.Fatalf("%v: unexpected %v", patch, err)
+		}
+		var pe *config.PatchError
+		if !errors.As(err, &pe) {
+			t.Fatalf("%v: unexpected %T", patch, err)
+		}
+	}
+	if v := conf.P("name").String(); v != "svc" {
+		t.Fatalf("partially applied: %v", v)
+	}
+
+	var err error
+	conf.Err(&err).ApplyPatch([]byte(`[{"op": "jump", "path": "/name"}]`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	conf.Err(&err).ApplyPatch([]byte(`{}`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func Test_ApplyPatch_Source(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(patchTestConfig)).Load()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := config.NewSource(func(opts *config.NewSource_Options) {
+		opts.Config = conf
+		opts.Context = ctx
+		opts.UpdatePeriod = time.Hour
+	})
+
+	before := source.Config
+	var err error
+	conf.P("limits").Err(&err).ApplyPatch([]byte(`[
+		{"op": "add", "path": "/burst", "value": 10},
+		{"op": "test", "path": "/rps", "value": 1}
+	]`))
+	var pe *config.PatchError
+	if !errors.As(err, &pe) || pe.Index != 1 || !errors.Is(err, config.ErrPatchTestFailed) {
+		t.Fatalf("unexpected %v", err)
+	}
+	if source.Config.Has("limits", "burst") {
+		t.Fatal("partially applied")
+	}
+
+	// a malformed index is an error, and the updater survives it
+	err = nil
+	conf.Err(&err).ApplyPatch([]byte(`[{"op": "replace", "path": "/servers/-1", "value": 1}]`))
+	if !errors.Is(err, config.ErrInvalidPath) {
+		t.Fatalf("unexpected %v", err)
+	}
+
+	err = nil
+	conf.P("limits").Err(&err).ApplyPatch([]byte(`[
+		{"op": "add", "path": "/burst", "value": 10},
+		{"op": "test", "path": "/rps", "value": 100}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := source.Config.P("limits", "burst").Int(); v != 10 {
+		t.Fatalf("unexpected %v", v)
+	}
+	// the readers of the old snapshot aren't affected
+	if before.Has("limits", "burst") {
+		t.Fatal("the old snapshot changed")
+	}
+}
+
+func Test_ApplyPatch_Root(t *testing.T) {
+	// a root list, patched via the Err() copy
+	conf := (&config.InitContext{}).FromBytes([]byte(`[1, 2]`)).Load()
+	var err error
+	conf.Err(&err).NonThreadSafe_ApplyPatch([]byte(`[{"op": "add", "path": "/-", "value": 3}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := conf.List(); len(l) != 3 || conf.P("2").Int() != 3 {
+		t.Fatalf("unexpected %v", conf.DataSubTree)
+	}
+
+	// the root map replaced by a list
+	conf = (&config.InitContext{}).FromBytes([]byte(patchTestConfig)).Load()
+	conf.Err(&err).NonThreadSafe_ApplyPatch([]byte(`[{"op": "replace", "path": "", "value": ["x"]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := conf.List(); len(l) != 1 || l[0] != "x" {
+		t.Fatalf("unexpected %v", conf.DataSubTree)
+	}
+
+	// a string replaced by a map, via the copy of the P()
+	conf = (&config.InitContext{}).FromBytes([]byte(patchTestConfig)).Load()
+	conf.P("name").Err(&err).NonThreadSafe_ApplyPatch([]byte(`[{"op": "replace", "path": "", "value": {"first": "svc"}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := conf.P("name", "first").String(); v != "svc" {
+		t.Fatalf("unexpected %v", conf.DataSubTree)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Pointer_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes([]byte(`{
+		"hosts": {
+			"example.com/api": {"port": 443},
+			"a~b": {"port": 80},
+			"": {"port": 1}
+		},
+		"servers": [{"host": "a"}, {"host": "b"}]
+	}`)).Load()
+
+	cases := map[string]int{
+		"/hosts/example.com~1api/port": 443,
+		"/hosts/a~0b/port":             80,
+		"/hosts//port":                 1,
+	}
+	for pointer, port := range cases {
+		c := conf.Pointer(pointer)
+		if v := c.Int(); v != port {
+			t.Fatalf("%v: unexpected %v", pointer, v)
+		}
+		if s := c.PointerString(); s != pointer {
+			t.Fatalf("%v: unexpected pointer string %v", pointer, s)
+		}
+	}
+
+	c := conf.P("servers").Pointer("/1")
+	if v := c.P("host").String(); v != "b" {
+		t.Fatalf("unexpected %v", v)
+	}
+	if s := c.P("host").PointerString(); s != "/servers/1/host" {
+		t.Fatalf("unexpected %v", s)
+	}
+	if s := conf.PointerString(); s != "" {
+		t.Fatalf("unexpected %q", s)
+	}
+	if v := conf.Pointer("").P("servers", "0", "host").String(); v != "a" {
+		t.Fatalf("unexpected %v", v)
+	}
+
+	for _, pointer := range []string{"hosts", "/hosts/a~2b", "/servers/9"} {
+		var err error
+		conf.Err(&err).Pointer(pointer).String()
+		if err == nil {
+			t.Fatalf("%v: expected an error", pointer)
+		}
+		t.Logf("%v", err)
+	}
+	var err error
+	conf.Err(&err).Pointer("/hosts/nope")
+	var nfe *config.NotFoundError
+	if !errors.Is(err, config.ErrNotFound) || !errors.As(err, &nfe) || !reflect.DeepEqual(nfe.Path, []string{"hosts", "nope"}) {
+		t.Fatalf("unexpected %v", err)
+	}
+
+	// RFC 6901 list indices are 0 or without the sign and leading zeros
+	for _, pointer := range []string{"/servers/-1", "/servers/+1", "/servers/01", "/servers/-"} {
+		err = nil
+		conf.Err(&err).Pointer(pointer)
+		if !errors.Is(err, config.ErrInvalidPath) {
+			t.Fatalf("%v: unexpected %v", pointer, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+var queryTestData = []byte(`
+servers:
+  - host: a
+    enabled: true
+    weight: 1
+  - host: b
+    enabled: false
+    weight: 5
+  - host: c
+    enabled: true
+    weight: 10
+clients:
+  http:
+    timeout: 1s
+    retry:
+      timeout: 2s
+  grpc:
+    timeout: 3s
+`)
+
+func queryPaths(cc []*config.Config) []string {
+	paths := []string{}
+	for _, c := range cc {
+		paths = append(paths, strings.Join(c.GetCurrentLocationPlusPath(), "."))
+	}
+	return paths
+}
+
+func Test_Query_1(t *testing.T) {
+	conf := (&config.InitContext{}).FromBytes(queryTestData).Load()
+
+	cases := []struct {
+		pattern  []string
+		expected []string
+	}{
+		{[]string{"servers", "*", "host"}, []string{"servers.0.host", "servers.1.host", "servers.2.host"}},
+		{[]string{"clients", "**", "timeout"}, []string{"clients.grpc.timeout", "clients.http.timeout", "clients.http.retry.timeout"}},
+		{[]string{"servers", "[1:3]", "host"}, []string{"servers.1.host", "servers.2.host"}},
+		{[]string{"servers", "[-1:]"}, []string{"servers.2"}},
+		{[]string{"servers", "[?enabled==true]", "host"}, []string{"servers.0.host", "servers.2.host"}},
+		{[]string{"servers", "[?weight>=5]"}, []string{"servers.1", "servers.2"}},
+		{[]string{"servers", "[?host!='b']", "weight"}, []string{"servers.0.weight", "servers.2.weight"}},
+		{[]string{"clients", "[?retry]"}, []string{"clients.http"}},
+		{[]string{"nope", "*"}, []string{}},
+	}
+	for _, tc := range cases {
+		if paths := queryPaths(conf.Query(tc.pattern...)); !reflect.DeepEqual(paths, tc.expected) {
+			t.Fatalf("%v: expected %v, got %v", tc.pattern, tc.expected, paths)
+		}
+	}
+
+	hosts := []string{}
+	for _, c := range conf.P("servers").Query("[?enabled==true]", "host") {
+		hosts = append(hosts, c.String())
+	}
+	if !reflect.DeepEqual(hosts, []string{"a", "c"}) {
+		t.Fatalf("unexpected %v", hosts)
+	}
+
+	for _, c := range conf.P("clients").Query("**", "[?timeout]") {
+		c.Set([]string{"timeout"}, "10s")
+	}
+	if v := conf.P("clients", "http", "retry", "timeout").String(); v != "10s" {
+		t.Fatalf("unexpected %v", v)
+	}
+
+	var err error
+	conf.Err(&err).Query("servers", "[a:b]")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rusriver/config/v2"
+)
+
+func Test_Schema_1_Valid(t *testing.T) {
+	schema := (&config.InitContext{}).FromFile("conf-test-files/schema/schema.json").Load()
+	conf := (&config.InitContext{}).FromFile("conf-test-files/schema/valid.yaml").Load()
+	if err := conf.ValidateSchema(schema); err != nil {
+		t.Fatalf("%v", err)
+	}
+}
+
+func Test_Schema_2_Violations(t *testing.T) {
+	schema := (&config.InitContext{}).FromFile("conf-test-files/schema/schema.json").Load()
+	conf := (&config.InitContext{}).FromFile("conf-test-files/schema/invalid.yaml").Load()
+	err := conf.ValidateSchema(schema)
+	var se *config.SchemaError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected *SchemaError, got %v", err)
+	}
+	t.Logf("%v", err)
+
+	expected := map[string][]string{
+		"pattern":              {"name"},
+		"enum":                 {"mode"},
+		"maximum":              {"server", "port"},
+		"type":                 {"replicas", "0", "port"},
+		"required":             {"replicas", "1", "port"},
+		"additionalProperties": {"extra"},
+	}
+	if len(se.Violations) != len(expected) {
+		t.Fatalf("expected %v violations, got %v", len(expected), len(se.Violations))
+	}
+	for _, v := range se.Violations {
+		if !reflect.DeepEqual(expected[v.Keyword], v.Path) {
+			t.Fatalf("unexpected path %v for the keyword %v", v.Path, v.Keyword)
+		}
+		if v.Origin != "conf-test-files/schema/invalid.yaml" {
+			t.Fatalf("unexpected origin %q", v.Origin)
+		}
+	}
+}
+
+func Test_Schema_3_SubTree(t *testing.T) {
+	schema := (&config.InitContext{}).FromFile("conf-test-files/schema/schema.json").Load()
+	conf := (&config.InitContext{}).FromFile("conf-test-files/schema/invalid.yaml").Load()
+	err := conf.P("server").ValidateSchema(schema.P("$defs", "server"))
+	var se *config.SchemaError
+	if !errors.As(err, &se) || len(se.Violations) != 1 || !reflect.DeepEqual(se.Violations[0].Path, []string{"port"}) {
+		t.Fatalf("unexpected %v", err)
+	}
+}
+
+func Test_Schema_4_SubTreeRefs(t *testing.T) {
+	schema := (&config.InitContext{}).FromFile("conf-test-files/schema/schema.json").Load()
+	conf := (&config.InitContext{}).FromFile("conf-test-files/schema/invalid.yaml").Load()
+	// the "#/$defs/server" of the items resolves against the whole schema
+	err := conf.P("replicas").ValidateSchema(schema.P("properties", "replicas"))
+	var se *config.SchemaError
+	if !errors.As(err, &se) || len(se.Violations) != 2 {
+		t.Fatalf("unexpected %v", err)
+	}
+	for _, v := range se.Violations {
+		if v.Keyword == "$ref" {
+			t.Fatalf("unresolved ref: %v", v)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -159,3 +160,46 @@ func Test_20230622_1(t *testing.T) {
 
 	configSource.Config.PrintJson("INIT")
 }
+
+func Test_ListConfig_MapConfig_Set(t *testing.T) {
+	var err error
+	conf := (&config.InitContext{}).FromBytes([]byte(`
+servers:
+  - host: a
+  - host: b
+labels:
+  team: core
+  tier: backend
+`)).Err(&err).Load()
+
+	configSource := config.NewSource(func(opts *config.NewSource_Options) {
+		opts.Config = conf
+		opts.CommandBufferSize = 10
+		opts.UpdatePeriod = time.Hour
+	})
+
+	for i, server := range conf.P("servers").ListConfig() {
+		if p := server.GetCurrentLocationPlusPath(); !reflect.DeepEqual(p, []string{"servers", strconv.Itoa(i)}) {
+			t.Fatalf("unexpected location %v", p)
+		}
+		server.Set([]string{"port"}, 8080+i)
+	}
+	for k, label := range conf.P("labels").MapConfig() {
+		label.Set(nil, k+"-"+label.String())
+	}
+
+	chDown := make(chan struct{})
+	configSource.ChFlushSignal <- &config.MsgFlushSignal{ChDown: chDown}
+	<-chDown
+
+	c := configSource.Config
+	if v := c.P("servers", "1", "port").Int(); v != 8081 {
+		t.Fatalf("unexpected port %v", v)
+	}
+	if v := c.P("servers", "0", "host").String(); v != "a" {
+		t.Fatalf("unexpected host %v", v)
+	}
+	if v := c.P("labels", "tier").String(); v != "tier-backend" {
+		t.Fatalf("unexpected label %v", v)
+	}
+}
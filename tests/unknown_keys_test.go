@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rusriver/config/v2"
+)
+
+type unknownKeysTestServer struct {
+	Host    string        `yaml:"host"`
+	Port    int           `yaml:"port"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type unknownKeysTestApp struct {
+	Name    string                 `yaml:"name"`
+	Server  *unknownKeysTestServer `yaml:"server"`
+	Clients []struct {
+		Name    string `yaml:"name"`
+		Retries int    `yaml:"retries"`
+	} `yaml:"clients"`
+	Labels map[string]string `yaml:"labels"`
+	Ignore string            `yaml:"-"`
+}
+
+func checkUnknownKeys(t *testing.T, unknown []config.UnknownKey, expected map[string]string) {
+	t.Helper()
+	if len(unknown) != len(expected) {
+		t.Fatalf("expected %v unknown keys, got %v", expected, unknown)
+	}
+	for _, u := range unknown {
+		t.Logf("%v", u)
+		s, ok := expected[strings.Join(u.Path, ".")]
+		if !ok {
+			t.Fatalf("unexpected unknown key %v", u)
+		}
+		if !reflect.DeepEqual(config.SplitPathToParts(s), u.Suggestion) && !(s == "" && u.Suggestion == nil) {
+			t.Fatalf("unexpected suggestion for %v", u)
+		}
+	}
+}
+
+func Test_UnknownKeys_1_Struct(t *testing.T) {
+	conf := (&config.InitContext{}).FromFile("conf-test-files/unknown-keys/app.yaml").Load()
+	unknown := conf.UnknownKeys(config.KnownKeysFromStruct(unknownKeysTestApp{}))
+	checkUnknownKeys(t, unknown, map[string]string{
+		"server.timout":     "server.timeout",
+		"clients.1.retires": "clients.1.retries",
+		"debug":             "",
+	})
+}
+
+func Test_UnknownKeys_2_Schema(t *testing.T) {
+	schema := (&config.InitContext{}).FromBytes([]byte(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"debug": {"type": "boolean"},
+			"labels": {"type": "object"},
+			"server": {"$ref": "#/$defs/server"},
+			"clients": {"type": "array", "items": {"properties": {"name": {}, "retries": {}}}}
+		},
+		"$defs": {"server": {"properties": {"host": {}, "port": {}, "timeout": {}}}}
+	}`)).Load()
+	conf := (&config.InitContext{}).FromFile("conf-test-files/unknown-keys/app.yaml").Load()
+	unknown := conf.UnknownKeys(config.KnownKeysFromSchema(schema))
+	checkUnknownKeys(t, unknown, map[string]string{
+		"server.timout":     "server.timeout",
+		"clients.1.retires": "clients.1.retries",
+	})
+}
+
+func Test_UnknownKeys_3_PathsFail(t *testing.T) {
+	conf := (&config.InitContext{}).FromFile("conf-test-files/unknown-keys/app.yaml").Load()
+	known := config.KnownKeysFromPaths(
+		[]string{"name"},
+		[]string{"server", "host"},
+		[]string{"server", "port"},
+		[]string{"clients"},
+		[]string{"labels"},
+		[]string{"debug"},
+	)
+	var err error
+	unknown := conf.Err(&err).UnknownKeys(known, func(opts *config.UnknownKeys_Options) {
+		opts.Fail = true
+	})
+	checkUnknownKeys(t, unknown, map[string]string{
+		"server.timout": "",
+	})
+	var uke *config.UnknownKeysError
+	if !errors.As(err, &uke) || len(uke.Keys) != 1 {
+		t.Fatalf("unexpected %v", err)
+	}
+}
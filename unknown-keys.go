@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KnownKeys is a tree of the keys, which the application knows about, to find the unknown
+// ones in the config, see UnknownKeys().
+type KnownKeys struct {
+	children map[string]*KnownKeys // nil for leaves
+	any      *KnownKeys            // for all the list items, or all the keys of a map
+	open     bool                  // anything below is known
+}
+
+func newKnownKeysNode() *KnownKeys {
+	return &KnownKeys{children: map[string]*KnownKeys{}}
+}
+
+// The keys described by the JSON Schema: the properties, additionalProperties and items,
+// with the $ref resolved. Objects without properties and additionalProperties are free-form.
+func KnownKeysFromSchema(schema *Config) *KnownKeys {
+	sv := &schemaValidator{root: schemaRoot(schema)}
+	return knownKeysFromSchema(sv, schema.DataSubTree, 0)
+}
+
+func knownKeysFromSchema(sv *schemaValidator, schema interface{}, refDepth int) *KnownKeys {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		// bool schemas
+		return &KnownKeys{open: schema == true}
+	}
+	if ref, ok := s["$ref"].(string); ok && refDepth < schemaMaxRefDepth {
+		if target, err := sv.resolveRef(ref); err == nil {
+			return knownKeysFromSchema(sv, target, refDepth+1)
+		}
+	}
+	properties, hasProperties := s["properties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	items, hasItems := s["items"]
+	if !hasProperties && !hasAdditional && !hasItems {
+		return &KnownKeys{open: s["type"] == "object" || s["type"] == "array"}
+	}
+	k := newKnownKeysNode()
+	for name, ps := range properties {
+		k.children[name] = knownKeysFromSchema(sv, ps, refDepth)
+	}
+	if hasAdditional {
+		if b, ok := additional.(bool); ok {
+			k.open = b
+		} else {
+			k.any = knownKeysFromSchema(sv, additional, refDepth)
+		}
+	}
+	if hasItems {
+		k.any = knownKeysFromSchema(sv, items, refDepth)
+	}
+	return k
+}
+
+// The keys of the struct, as the yaml (or, if there's no yaml tag, the json) decoding would
+// see them: the tag names, or the lowercased field names. Maps and slices of structs are
+// followed, and the interface{} fields are free-form.
+func KnownKeysFromStruct(v interface{}) *KnownKeys {
+	return knownKeysFromType(reflect.TypeOf(v), map[reflect.Type]*KnownKeys{})
+}
+
+func knownKeysFromType(t reflect.Type, seen map[reflect.Type]*KnownKeys) *KnownKeys {
+	if t == nil {
+		return &KnownKeys{open: true}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if k, ok := seen[t]; ok {
+		return k
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return &KnownKeys{open: true}
+	case reflect.Map, reflect.Slice, reflect.Array:
+		k := newKnownKeysNode()
+		seen[t] = k
+		k.any = knownKeysFromType(t.Elem(), seen)
+		return k
+	case reflect.Struct:
+		k := newKnownKeysNode()
+		seen[t] = k
+		addStructFields(k, t, seen)
+		return k
+	}
+	return &KnownKeys{}
+}
+
+func addStructFields(k *KnownKeys, t reflect.Type, seen map[reflect.Type]*KnownKeys) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag, hasTag := f.Tag.Lookup("yaml")
+		if !hasTag {
+			tag = f.Tag.Get("json")
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && (strings.Contains(flags, "inline") || (f.Anonymous && name == "")) {
+			addStructFields(k, ft, seen)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		k.children[name] = knownKeysFromType(f.Type, seen)
+	}
+}
+
+// The known paths, e.g. the accessed ones; everything below them is known too.
+func KnownKeysFromPaths(paths ...[]string) *KnownKeys {
+	k := newKnownKeysNode()
+	for _, path := range paths {
+		node := k
+		for _, part := range path {
+			if node.children == nil {
+				node.children = map[string]*KnownKeys{}
+			}
+			next, ok := node.children[part]
+			if !ok {
+				next = &KnownKeys{}
+				node.children[part] = next
+			}
+			node = next
+		}
+		node.open = true
+	}
+	return k
+}
+
+// UnknownKey is the key found by UnknownKeys(), with the Suggestion of a known key, if there's
+// a similar one. The paths are relative to the checked config, as used with P().
+type UnknownKey struct {
+	Path       []string
+	Suggestion []string
+	Origin     string
+}
+
+func (u UnknownKey) String() string {
+	s := fmt.Sprintf("unknown key %q", strings.Join(u.Path, "."))
+	if u.Suggestion != nil {
+		s += fmt.Sprintf(", did you mean %q?", strings.Join(u.Suggestion, "."))
+	}
+	if u.Origin != "" {
+		s += fmt.Sprintf(" (in %v)", u.Origin)
+	}
+	return s
+}
+
+type UnknownKeys_Options struct {
+	Fail bool // report the UnknownKeysError, if any unknown keys were found
+}
+
+// UnknownKeys() returns the keys of the config at the current location, which are not known,
+// sorted by path. E.g., to fail on typos at startup:
+//
+//	conf.Err(&err).UnknownKeys(config.KnownKeysFromStruct(AppConfig{}), func(opts *config.UnknownKeys_Options) {
+//		opts.Fail = true
+//	})
+func (c *Config) UnknownKeys(known *KnownKeys, f ...func(opts *UnknownKeys_Options)) []UnknownKey {
+	opts := &UnknownKeys_Options{}
+	for _, f := range f {
+		f(opts)
+	}
+	var unknown []UnknownKey
+	known.find(nil, c.DataSubTree, func(path []string, parent *KnownKeys, inList bool) {
+		u := UnknownKey{Path: path, Origin: c.origin}
+		candidates := make([]string, 0, len(parent.children))
+		for name := range parent.children {
+			candidates = append(candidates, name)
+		}
+		sort.Strings(candidates)
+		if s := suggest(path[len(path)-1], candidates); s != "" && !inList {
+			u.Suggestion = appendPath(path[:len(path)-1], s)
+		}
+		unknown = append(unknown, u)
+	})
+	if opts.Fail && len(unknown) > 0 {
+		c.handleError(&UnknownKeysError{Keys: unknown})
+	}
+	return unknown
+}
+
+func (k *KnownKeys) find(path []string, v interface{}, report func(path []string, parent *KnownKeys, inList bool)) {
+	if k.open || k.children == nil && k.any == nil {
+		// leaves may have any value, it's for the ValidateSchema() to check
+		return
+	}
+	check := func(key string, x interface{}, inList bool) {
+		child, ok := k.children[key]
+		if !ok {
+			child = k.any
+		}
+		if child == nil {
+			report(appendPath(path, key), k, inList)
+			return
+		}
+		child.find(appendPath(path, key), x, report)
+	}
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vv))
+		for key := range vv {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			check(key, vv[key], false)
+		}
+	case []interface{}:
+		for i, x := range vv {
+			check(strconv.Itoa(i), x, true)
+		}
+	}
+}
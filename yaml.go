@@ -1,33 +1,56 @@
 package config
 
 import (
-	"os"
+	"path/filepath"
 
 	yaml "gopkg.in/yaml.v3"
 )
 
 // parseYamlFile reads a YAML configuration from the given filename.
-func parseYamlFile(filename string) (*Config, error) {
-	c, err := os.ReadFile(filename)
+func parseYamlFile(inc *includer, filename string) (*Config, error) {
+	c, err := inc.readFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return parseYaml(c)
+	return parseYaml(inc, filepath.Dir(filename), c)
 }
 
-// parseYaml performs the real YAML parsing.
-func parseYaml(c []byte) (*Config, error) {
+// parseYaml performs the real YAML parsing. Includes are resolved relative to the dir.
+func parseYaml(inc *includer, dir string, c []byte) (*Config, error) {
+	var node yaml.Node
 	var out interface{}
 	var err error
-	if err = yaml.Unmarshal(c, &out); err != nil {
+	if err = yaml.Unmarshal(c, &node); err != nil {
+		return nil, &yamlSyntaxError{err: err}
+	}
+	if err = inc.resolveYamlTags(dir, &node); err != nil {
+		return nil, err
+	}
+	if err = node.Decode(&out); err != nil {
 		return nil, err
 	}
 	if out, err = normalizeValue(out); err != nil {
 		return nil, err
 	}
+	if out, err = inc.resolveDirectives(dir, out); err != nil {
+		return nil, err
+	}
 	return &Config{DataSubTree: out}, nil
 }
 
+// The data isn't a YAML at all, unlike the errors of the includes, or the directives.
+type yamlSyntaxError struct {
+	err error
+}
+
+func (e *yamlSyntaxError) Error() string {
+	return e.err.Error()
+}
+
+func (e *yamlSyntaxError) Unwrap() error {
+	return e.err
+}
+
 // RenderYaml renders a YAML configuration.
 func RenderYaml(c interface{}) (string, error) {
 	b, err := yaml.Marshal(c)